@@ -0,0 +1,299 @@
+package main
+
+import (
+	config "company-service/configs"
+	"company-service/db/migrations"
+	"company-service/internal/audit"
+	"company-service/internal/auth"
+	"company-service/internal/company"
+	"company-service/internal/db"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runCommand(cfg *config.Config, name string, args []string) {
+	commands := map[string]func(context.Context, *config.Config, *sql.DB, []string) error{
+		"verify-audit-chain":      verifyAuditChain,
+		"export-audit-checkpoint": exportAuditCheckpoint,
+		"import":                  importCompanies,
+		"replay-dead-letters":     replayDeadLetters,
+		"republish-snapshot":      republishSnapshot,
+		"migrate":                 migrate,
+	}
+
+	command, ok := commands[name]
+	if !ok {
+		log.Fatalf("Unknown command %q", name)
+	}
+
+	database, err := db.Connect()
+	if err != nil {
+		log.Fatalf("Could not connect to the database: %v", err)
+	}
+	defer func() {
+		if err := database.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	if err := command(context.Background(), cfg, database, args); err != nil {
+		log.Printf("%s failed: %v", name, err)
+		os.Exit(1)
+	}
+}
+
+// verifyAuditChain walks the audit chain and, when given the path of a
+// checkpoint file exported earlier, checks the chain still matches it.
+func verifyAuditChain(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	result, err := audit.Verify(ctx, database)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return fmt.Errorf("audit chain is broken at entry %d: %s", result.FirstBrokenSeq, result.Reason)
+	}
+	log.Printf("Audit chain is valid: %d entries verified, head %d %s", result.EntriesVerified, result.Head.Seq, result.Head.Hash)
+
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read checkpoint: %v", err)
+		}
+		var checkpoint audit.Checkpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			return fmt.Errorf("could not parse checkpoint %s: %v", path, err)
+		}
+		key := loadAuditSigningKey(cfg)
+		if key == nil {
+			return fmt.Errorf("AUDIT_SIGNING_KEY is required to verify checkpoints")
+		}
+		if err := audit.VerifyCheckpoint(ctx, database, key.Public().(ed25519.PublicKey), checkpoint); err != nil {
+			return fmt.Errorf("checkpoint %s: %v", path, err)
+		}
+		log.Printf("Checkpoint %s matches entry %d", path, checkpoint.Seq)
+	}
+	return nil
+}
+
+// exportAuditCheckpoint prints a signed checkpoint of the current chain head.
+func exportAuditCheckpoint(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	key := loadAuditSigningKey(cfg)
+	if key == nil {
+		return fmt.Errorf("AUDIT_SIGNING_KEY is not set")
+	}
+
+	head, err := audit.Head(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(audit.SignCheckpoint(key, head, time.Now()))
+}
+
+// importCompanies loads companies from a CSV or JSONL file, or stdin when the
+// path is "-", and prints the import report.
+func importCompanies(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "input format, csv or jsonl (default: from the file extension)")
+	upsert := flags.Bool("upsert", false, "update companies with the same name instead of creating duplicates")
+	dryRun := flags.Bool("dry-run", false, "validate and report without changing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import [--format csv|jsonl] [--upsert] [--dry-run] <file|->")
+	}
+	path := flags.Arg(0)
+
+	options := &proto.ImportOptions{DryRun: *dryRun}
+	if *upsert {
+		options.Mode = proto.ImportMode_UPSERT
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	switch *format {
+	case "csv":
+		options.Format = proto.ImportFormat_CSV
+	case "jsonl", "ndjson":
+		options.Format = proto.ImportFormat_JSONL
+	default:
+		return fmt.Errorf("unknown import format %q", *format)
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	kafkaProducer := newEventProducer(cfg, database)
+	defer func() {
+		if err := kafkaProducer.Close(); err != nil {
+			log.Printf("Error closing Kafka producer: %v", err)
+		}
+	}()
+	companyService := company.NewCompanyServiceImpl(auth.NewAuthService(cfg.JWTSecret), database, kafkaProducer)
+	companyService.EventEncoding = loadEventEncoding(cfg)
+	companyService.CloudEvents = loadCloudEvents(cfg)
+	companyService.PublishTimeout = cfg.EventPublishTimeout
+	companyService.UniqueKeys = loadUniqueKeys(cfg)
+
+	// An import that stops early still reports the rows it imported.
+	report, err := companyService.Import(audit.ContextWithActor(ctx, audit.SystemActor("import")), input, options)
+	if report == nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		return encodeErr
+	}
+	return err
+}
+
+// replayDeadLetters publishes the events that exhausted their retries, in the
+// order they failed, to the sinks they failed on.
+func replayDeadLetters(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	deadLetters := newDeadLetterQueue(cfg, database)
+	if deadLetters == nil {
+		return fmt.Errorf("KAFKA_DEAD_LETTERS is none")
+	}
+
+	eventSinks := make(map[string]kafka.Producer)
+	var producers []kafka.Producer
+	for _, sink := range newEventSinks(cfg, database) {
+		eventSinks[sink.name] = sink.producer
+		producers = append(producers, sink.producer)
+	}
+	// Letters dead-lettered before they recorded their sink go to every sink.
+	eventSinks[""] = fanOut(producers)
+	defer func() {
+		if err := eventSinks[""].Close(); err != nil {
+			log.Printf("Error closing event sinks: %v", err)
+		}
+	}()
+
+	replayed, err := deadLetters.Replay(ctx, eventSinks)
+	log.Printf("Replayed %d dead letters", replayed)
+	return err
+}
+
+// republishSnapshot publishes the current state of every company to the
+// compacted snapshots topic and prints the result.
+func republishSnapshot(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	flags := flag.NewFlagSet("republish-snapshot", flag.ContinueOnError)
+	companyType := flags.String("type", "", "only companies of this type")
+	nameContains := flags.String("name-contains", "", "only companies whose name contains this text")
+	includeDeleted := flags.Bool("include-deleted", false, "publish soft-deleted companies instead of tombstones")
+	rate := flags.Float64("rate", 100, "maximum events per second, 0 for unlimited")
+	checkpoint := flags.String("checkpoint", "default", "checkpoint to resume from and record progress in, empty to disable")
+	restart := flags.Bool("restart", false, "ignore the checkpoint and start from the first company")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := kafka.EnsureCompactedTopic(ctx, kafkaConfig(cfg), cfg.KafkaTopicSnapshots, cfg.KafkaSnapshotPartitions, cfg.KafkaSnapshotReplication); err != nil {
+		return fmt.Errorf("could not create snapshots topic %s: %v", cfg.KafkaTopicSnapshots, err)
+	}
+
+	snapshotProducer := newSnapshotProducer(cfg)
+	defer func() {
+		if err := snapshotProducer.Close(); err != nil {
+			log.Printf("Error closing Kafka producer: %v", err)
+		}
+	}()
+	companyService := company.NewCompanyServiceImpl(auth.NewAuthService(cfg.JWTSecret), database, nil)
+	companyService.EventEncoding = loadEventEncoding(cfg)
+	companyService.CloudEvents = loadCloudEvents(cfg)
+	companyService.PublishTimeout = cfg.EventPublishTimeout
+	companyService.SnapshotProducer = snapshotProducer
+
+	resp, err := companyService.RepublishSnapshot(audit.ContextWithActor(ctx, audit.SystemActor("snapshot")), &proto.RepublishSnapshotRequest{
+		Type:           *companyType,
+		NameContains:   *nameContains,
+		IncludeDeleted: *includeDeleted,
+		Rate:           *rate,
+		Checkpoint:     *checkpoint,
+		Restart:        *restart,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(resp)
+}
+
+// migrate applies or reverts the embedded schema migrations:
+// up, down [n], status or force <version>.
+func migrate(ctx context.Context, cfg *config.Config, database *sql.DB, args []string) error {
+	usage := fmt.Errorf("usage: migrate up | down [n] | status | force <version>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	migrator, err := db.NewMigrator(database, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		log.Printf("Applied %d migrations", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return usage
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		log.Printf("Reverted %d migrations", reverted)
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d\ndirty: %t\n", status.Version, status.Dirty)
+		for _, migration := range status.Pending {
+			fmt.Printf("pending: %d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	case "force":
+		if len(args) != 2 {
+			return usage
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return usage
+		}
+		return migrator.Force(ctx, version)
+	default:
+		return usage
+	}
+}
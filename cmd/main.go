@@ -2,15 +2,30 @@ package main
 
 import (
 	"company-service/configs"
+	"company-service/db/migrations"
+	"company-service/internal/audit"
 	"company-service/internal/auth"
+	"company-service/internal/changefeed"
+	"company-service/internal/commands"
 	"company-service/internal/company"
 	"company-service/internal/db"
+	"company-service/internal/events"
+	"company-service/internal/idempotency"
 	"company-service/internal/kafka"
+	"company-service/internal/ratelimit"
+	"company-service/internal/sinks"
+	"company-service/internal/webhooks"
 	"company-service/proto"
+	"context"
+	"crypto/ed25519"
+	"database/sql"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"log"
 	"net"
+	"os"
+	"slices"
+	"time"
 )
 
 func main() {
@@ -20,6 +35,11 @@ func main() {
 		log.Fatalf("Could not load config: %v", err)
 	}
 
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1], os.Args[2:])
+		return
+	}
+
 	authService := auth.NewAuthService(cfg.JWTSecret)
 
 	database, err := db.Connect()
@@ -32,17 +52,128 @@ func main() {
 		}
 	}()
 
-	kafkaProducer := kafka.NewKafkaProducer(cfg.KafkaBroker, cfg.KafkaTopicCompanyEvents)
+	if cfg.DBAutoMigrate {
+		migrator, err := db.NewMigrator(database, migrations.FS)
+		if err != nil {
+			log.Fatalf("Could not load migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatalf("Could not migrate the database: %v", err)
+		}
+	}
+
+	kafkaProducer := newEventProducer(cfg, database)
 	defer func() {
 		if err := kafkaProducer.Close(); err != nil {
 			log.Printf("Error closing Kafka producer: %v", err)
 		}
 	}()
 
+	snapshotProducer := newSnapshotProducer(cfg)
+	defer func() {
+		if err := snapshotProducer.Close(); err != nil {
+			log.Printf("Error closing Kafka producer: %v", err)
+		}
+	}()
+
 	companyService := company.NewCompanyServiceImpl(authService, database, kafkaProducer)
+	companyService.AuditSigningKey = loadAuditSigningKey(cfg)
+	companyService.EventEncoding = loadEventEncoding(cfg)
+	companyService.CloudEvents = loadCloudEvents(cfg)
+	companyService.PublishTimeout = cfg.EventPublishTimeout
+	companyService.UniqueKeys = loadUniqueKeys(cfg)
+	if err := companyService.UniqueKeys.EnsureIndexes(context.Background(), database); err != nil {
+		log.Fatalf("Could not index the unique company keys: %v", err)
+	}
+	companyService.SnapshotProducer = snapshotProducer
+	if cfg.WebhooksEnabled {
+		companyService.Webhooks = webhooks.NewStore(database)
+		companyService.WebhookPolicy = webhookPolicy(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if len(cfg.DatabaseReplicaURLs) > 0 {
+		replicas, err := db.ConnectReplicas(cfg)
+		if err != nil {
+			log.Fatalf("Could not connect to the read replicas: %v", err)
+		}
+		cluster := db.NewCluster(database, cfg.DBReplicaMaxLag, replicas...)
+		defer func() {
+			if err := cluster.Close(); err != nil {
+				log.Printf("Error closing read replicas: %v", err)
+			}
+		}()
+		go cluster.RunHealthChecks(ctx, cfg.DBReplicaCheckInterval)
+		companyService.Cluster = cluster
+	}
+	go func() {
+		if err := kafka.EnsureCompactedTopic(ctx, kafkaConfig(cfg), cfg.KafkaTopicSnapshots, cfg.KafkaSnapshotPartitions, cfg.KafkaSnapshotReplication); err != nil {
+			log.Printf("Could not create snapshots topic %s: %v", cfg.KafkaTopicSnapshots, err)
+		}
+	}()
+	if cfg.PurgeInterval > 0 {
+		go companyService.RunPurgeJob(ctx, cfg.PurgeInterval, cfg.PurgeRetention)
+	}
+
+	companyService.Changes = changefeed.NewFeed(database)
+	go func() {
+		if err := companyService.Changes.Listen(ctx, cfg.DatabaseURL, cfg.ChangeFeedPollInterval); err != nil {
+			log.Printf("Change feed listener stopped: %v", err)
+		}
+	}()
+	go companyService.Changes.RunPrune(ctx, time.Hour, cfg.ChangeFeedRetention)
+
+	if cfg.WebhooksEnabled {
+		dispatcher := webhooks.NewDispatcher(companyService.Webhooks, cfg.WebhookTimeout)
+		dispatcher.MaxAttempts = cfg.WebhookMaxAttempts
+		dispatcher.InitialBackoff = cfg.WebhookBackoff
+		dispatcher.MaxBackoff = cfg.WebhookMaxBackoff
+		dispatcher.DisableAfter = cfg.WebhookDisableAfter
+		dispatcher.Policy = webhookPolicy(cfg)
+		go dispatcher.Run(ctx, cfg.WebhookPollInterval)
+	}
+
+	// Rate limiting per IP comes before authentication so that calls with
+	// missing or invalid tokens are limited too, and per user after it.
+	var interceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
+	var rateLimitInterceptor *ratelimit.Interceptor
+	if cfg.RateLimitEnabled {
+		rateLimitInterceptor = newRateLimitInterceptor(cfg, database)
+		if lockouts, ok := rateLimitInterceptor.Lockouts.(*ratelimit.PostgresLockoutStore); ok {
+			go lockouts.RunCleanup(ctx, time.Hour)
+		}
+		interceptors = append(interceptors, rateLimitInterceptor.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, rateLimitInterceptor.StreamInterceptor)
+	}
+	interceptors = append(interceptors, authService.JWTInterceptor)
+	streamInterceptors = append(streamInterceptors, authService.JWTStreamInterceptor)
+	if rateLimitInterceptor != nil {
+		interceptors = append(interceptors, rateLimitInterceptor.UserUnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, rateLimitInterceptor.UserStreamInterceptor)
+	}
+
+	if companyService.Cluster != nil {
+		sessionInterceptor := db.NewSessionInterceptor(companyService.Cluster, slices.Concat(mutations, streamingMutations)...)
+		interceptors = append(interceptors, sessionInterceptor.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, sessionInterceptor.StreamInterceptor)
+	}
+
+	idempotencyInterceptor := idempotency.NewInterceptor(database, cfg.IdempotencyTTL, mutations...)
+	idempotencyInterceptor.Lease = cfg.IdempotencyLease
+	interceptors = append(interceptors, idempotencyInterceptor.UnaryInterceptor)
+	streamInterceptors = append(streamInterceptors, idempotencyInterceptor.StreamInterceptor)
+	go idempotencyInterceptor.RunCleanup(ctx, time.Hour)
+
+	if cfg.CommandsEnabled {
+		stopCommands := startCommandWorker(ctx, cfg, companyService, idempotencyInterceptor)
+		defer stopCommands()
+	}
 
 	server := grpc.NewServer(
-		grpc.UnaryInterceptor(authService.JWTInterceptor),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
 
 	proto.RegisterCompanyServiceServer(server, companyService)
@@ -59,3 +190,256 @@ func main() {
 		log.Fatalf("Failed to serve: %v", err)
 	}
 }
+
+// mutations are the unary RPCs that write companies.
+var mutations = []string{
+	"CreateCompany", "UpdateCompany", "DeleteCompany", "RestoreCompany",
+	"BatchCreateCompanies", "BatchUpdateCompanies", "BatchDeleteCompanies",
+}
+
+// streamingMutations write companies too but cannot be made idempotent.
+var streamingMutations = []string{"ImportCompanies"}
+
+func webhookPolicy(cfg *config.Config) webhooks.Policy {
+	return webhooks.Policy{AllowHTTP: cfg.WebhookAllowHTTP, AllowPrivateNetworks: cfg.WebhookAllowPrivate}
+}
+
+func newRateLimitInterceptor(cfg *config.Config, database *sql.DB) *ratelimit.Interceptor {
+	methodRules, err := ratelimit.ParseRules(cfg.RateLimitMethodLimits)
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_METHOD_LIMITS: %v", err)
+	}
+
+	var limiter ratelimit.Limiter
+	var lockouts ratelimit.LockoutStore
+	switch cfg.RateLimitBackend {
+	case "memory":
+		limiter, lockouts = ratelimit.NewMemoryLimiter(), ratelimit.NewMemoryLockoutStore()
+	case "postgres":
+		limiter, lockouts = ratelimit.NewPostgresLimiter(database), ratelimit.NewPostgresLockoutStore(database)
+	default:
+		log.Fatalf("Unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+
+	return ratelimit.NewInterceptor(
+		limiter,
+		lockouts,
+		ratelimit.Rule{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst},
+		methodRules,
+		ratelimit.LockoutPolicy{
+			MaxFailures: cfg.LoginMaxFailures,
+			Base:        cfg.LoginLockoutBase,
+			Max:         cfg.LoginLockoutMax,
+		},
+	)
+}
+
+func loadAuditSigningKey(cfg *config.Config) ed25519.PrivateKey {
+	if cfg.AuditSigningKey == "" {
+		return nil
+	}
+	key, err := audit.ParseSigningKey(cfg.AuditSigningKey)
+	if err != nil {
+		log.Fatalf("Invalid AUDIT_SIGNING_KEY: %v", err)
+	}
+	return key
+}
+
+func loadEventEncoding(cfg *config.Config) events.Encoding {
+	encoding, err := events.ParseEncoding(cfg.EventEncoding)
+	if err != nil {
+		log.Fatalf("Invalid EVENT_ENCODING: %v", err)
+	}
+	return encoding
+}
+
+func loadUniqueKeys(cfg *config.Config) company.UniqueKeys {
+	keys, err := company.ParseUniqueKeys(cfg.CompanyUniqueKeys)
+	if err != nil {
+		log.Fatalf("Invalid COMPANY_UNIQUE_KEYS: %v", err)
+	}
+	return keys
+}
+
+func loadCloudEvents(cfg *config.Config) events.CloudEvents {
+	mode, err := events.ParseCloudEventsMode(cfg.CloudEventsMode)
+	if err != nil {
+		log.Fatalf("Invalid CLOUDEVENTS_MODE: %v", err)
+	}
+	return events.CloudEvents{Mode: mode, Source: cfg.CloudEventsSource}
+}
+
+// newEventProducer returns the company events producer. An event that cannot
+// be published to a sink is dead-lettered for that sink only, so replaying it
+// does not duplicate it on the sinks that already have it.
+func newEventProducer(cfg *config.Config, database *sql.DB) kafka.Producer {
+	deadLetters := newDeadLetterQueue(cfg, database)
+	var producers []kafka.Producer
+	for _, sink := range newEventSinks(cfg, database) {
+		producer := kafka.NewResilientProducer(sink.producer, kafka.RetryPolicy{MaxAttempts: 1}, nil, deadLetters)
+		producer.Sink = sink.name
+		producers = append(producers, producer)
+	}
+	return fanOut(producers)
+}
+
+type eventSink struct {
+	name     string
+	producer kafka.Producer
+}
+
+// newEventSinks returns the sinks listed in EVENT_SINKS, plus the webhook
+// queue when webhooks are enabled. Kafka and NATS retry failed publishes
+// behind their own circuit breaker, so one sink being down does not stop the
+// others.
+func newEventSinks(cfg *config.Config, database *sql.DB) []eventSink {
+	var eventSinks []eventSink
+	for _, name := range cfg.EventSinks {
+		var producer kafka.Producer
+		switch name {
+		case "kafka":
+			producer = withRetries(cfg, newKafkaWriter(cfg))
+		case "nats":
+			sink, err := sinks.NewNATSSink(context.Background(), cfg.NATSURL, cfg.NATSStream, cfg.NATSSubject)
+			if err != nil {
+				log.Fatalf("Could not connect to NATS: %v", err)
+			}
+			producer = withRetries(cfg, sink)
+		case "file":
+			sink, err := sinks.NewFileSink(cfg.EventFile)
+			if err != nil {
+				log.Fatalf("Could not open EVENT_FILE: %v", err)
+			}
+			producer = sink
+		case "stdout":
+			producer = sinks.NewStdoutSink()
+		case "noop":
+			producer = sinks.Noop{}
+		default:
+			log.Fatalf("Unknown event sink %q in EVENT_SINKS", name)
+		}
+		eventSinks = append(eventSinks, eventSink{name: name, producer: producer})
+	}
+	if cfg.WebhooksEnabled {
+		eventSinks = append(eventSinks, eventSink{name: "webhooks", producer: webhooks.NewSink(webhooks.NewStore(database))})
+	}
+	return eventSinks
+}
+
+func fanOut(producers []kafka.Producer) kafka.Producer {
+	switch len(producers) {
+	case 0:
+		return sinks.Noop{}
+	case 1:
+		return producers[0]
+	default:
+		return sinks.NewFanOut(producers...)
+	}
+}
+
+func withRetries(cfg *config.Config, producer kafka.Producer) kafka.Producer {
+	return kafka.NewResilientProducer(
+		producer,
+		kafka.RetryPolicy{
+			MaxAttempts:    cfg.KafkaRetryMaxAttempts,
+			InitialBackoff: cfg.KafkaRetryBackoff,
+			MaxBackoff:     cfg.KafkaRetryMaxBackoff,
+			AttemptTimeout: cfg.KafkaAttemptTimeout,
+		},
+		kafka.NewCircuitBreaker(cfg.KafkaBreakerThreshold, cfg.KafkaBreakerCooldown),
+		nil,
+	)
+}
+
+func newKafkaWriter(cfg *config.Config) *kafka.KafkaProducer {
+	producer, err := kafka.NewKafkaProducer(kafkaConfig(cfg), cfg.KafkaTopicCompanyEvents)
+	if err != nil {
+		log.Fatalf("Invalid Kafka configuration: %v", err)
+	}
+	return producer
+}
+
+// newSnapshotProducer returns the producer for the compacted snapshots topic.
+// It has no dead-letter queue: a snapshot that fails is republished by
+// resuming from its checkpoint.
+func newSnapshotProducer(cfg *config.Config) kafka.Producer {
+	producer, err := kafka.NewKafkaProducer(kafkaConfig(cfg), cfg.KafkaTopicSnapshots)
+	if err != nil {
+		log.Fatalf("Invalid Kafka configuration: %v", err)
+	}
+	return withRetries(cfg, producer)
+}
+
+func kafkaConfig(cfg *config.Config) kafka.Config {
+	kafkaCfg := kafka.Config{
+		Brokers:      cfg.KafkaBrokers,
+		RequiredAcks: cfg.KafkaRequiredAcks,
+		Compression:  cfg.KafkaCompression,
+		Idempotent:   cfg.KafkaIdempotent,
+	}
+
+	if cfg.KafkaTLSEnabled {
+		tlsConfig, err := kafka.NewTLSConfig(cfg.KafkaTLSCAFile, cfg.KafkaTLSCertFile, cfg.KafkaTLSKeyFile, cfg.KafkaTLSSkipVerify)
+		if err != nil {
+			log.Fatalf("Invalid Kafka TLS configuration: %v", err)
+		}
+		kafkaCfg.TLS = tlsConfig
+	}
+
+	mechanism, err := kafka.NewSASLMechanism(cfg.KafkaSASLMechanism, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+	if err != nil {
+		log.Fatalf("Invalid Kafka SASL configuration: %v", err)
+	}
+	kafkaCfg.SASL = mechanism
+	return kafkaCfg
+}
+
+// startCommandWorker applies the commands pushed to the company commands
+// topic and returns a function that stops the worker.
+func startCommandWorker(ctx context.Context, cfg *config.Config, companyService *company.CompanyServiceImpl, idempotencyInterceptor *idempotency.Interceptor) func() {
+	consumer, err := kafka.NewKafkaConsumer(kafkaConfig(cfg), cfg.KafkaTopicCommands, cfg.KafkaCommandsGroupID)
+	if err != nil {
+		log.Fatalf("Invalid Kafka configuration: %v", err)
+	}
+	deadLetters, err := kafka.NewKafkaProducer(kafkaConfig(cfg), cfg.KafkaTopicCommandsDLQ)
+	if err != nil {
+		log.Fatalf("Invalid Kafka configuration: %v", err)
+	}
+
+	worker := commands.NewWorker(consumer, companyService, deadLetters, audit.SystemActor("kafka-commands"))
+	worker.Interceptor = idempotencyInterceptor.UnaryInterceptor
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := worker.Run(ctx); err != nil {
+			log.Fatalf("Command worker stopped: %v", err)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		if err := consumer.Close(); err != nil {
+			log.Printf("Error closing Kafka consumer: %v", err)
+		}
+		if err := deadLetters.Close(); err != nil {
+			log.Printf("Error closing Kafka producer: %v", err)
+		}
+	}
+}
+
+func newDeadLetterQueue(cfg *config.Config, database *sql.DB) kafka.DeadLetterQueue {
+	switch cfg.KafkaDeadLetters {
+	case "postgres":
+		return kafka.NewPostgresDeadLetters(database)
+	case "file":
+		return kafka.NewFileDeadLetters(cfg.KafkaDeadLetterFile)
+	case "none":
+		return nil
+	default:
+		log.Fatalf("Unknown KAFKA_DEAD_LETTERS %q", cfg.KafkaDeadLetters)
+		return nil
+	}
+}
@@ -3,14 +3,95 @@ package config
 import (
 	"github.com/spf13/viper"
 	"log"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	JWTSecret               string
 	AppPort                 string
 	DatabaseURL             string
+	DatabaseReplicaURLs     []string
+	DBReplicaMaxLag         time.Duration
+	DBReplicaCheckInterval  time.Duration
+	DBMaxConnections        int
+	DBMaxIdleConnections    int
+	DBConnMaxLifetime       time.Duration
+	DBConnMaxIdleTime       time.Duration
+	DBAutoMigrate           bool
+	CompanyUniqueKeys       []string
 	KafkaBroker             string
+	KafkaBrokers            []string
 	KafkaTopicCompanyEvents string
+	EventEncoding           string
+	CloudEventsMode         string
+	CloudEventsSource       string
+	EventSinks              []string
+	EventFile               string
+	NATSURL                 string
+	NATSStream              string
+	NATSSubject             string
+
+	KafkaRetryMaxAttempts int
+	KafkaRetryBackoff     time.Duration
+	KafkaRetryMaxBackoff  time.Duration
+	KafkaAttemptTimeout   time.Duration
+	KafkaBreakerThreshold int
+	KafkaBreakerCooldown  time.Duration
+	KafkaDeadLetters      string
+	KafkaDeadLetterFile   string
+	EventPublishTimeout   time.Duration
+
+	KafkaRequiredAcks  string
+	KafkaCompression   string
+	KafkaIdempotent    bool
+	KafkaTLSEnabled    bool
+	KafkaTLSCAFile     string
+	KafkaTLSCertFile   string
+	KafkaTLSKeyFile    string
+	KafkaTLSSkipVerify bool
+	KafkaSASLMechanism string
+	KafkaSASLUsername  string
+	KafkaSASLPassword  string
+
+	CommandsEnabled       bool
+	KafkaTopicCommands    string
+	KafkaTopicCommandsDLQ string
+	KafkaCommandsGroupID  string
+
+	WebhooksEnabled     bool
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookBackoff      time.Duration
+	WebhookMaxBackoff   time.Duration
+	WebhookDisableAfter int
+	WebhookPollInterval time.Duration
+	WebhookAllowHTTP    bool
+	WebhookAllowPrivate bool
+
+	KafkaTopicSnapshots      string
+	KafkaSnapshotPartitions  int
+	KafkaSnapshotReplication int
+
+	RateLimitEnabled      bool
+	RateLimitBackend      string
+	RateLimitRPS          float64
+	RateLimitBurst        int
+	RateLimitMethodLimits string
+	LoginMaxFailures      int
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+
+	AuditSigningKey string
+
+	PurgeInterval  time.Duration
+	PurgeRetention time.Duration
+
+	IdempotencyTTL   time.Duration
+	IdempotencyLease time.Duration
+
+	ChangeFeedPollInterval time.Duration
+	ChangeFeedRetention    time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -19,8 +100,77 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetDefault("JWT_SECRET", "mySecretKey")
 	viper.SetDefault("APP_PORT", "8080")
+	viper.SetDefault("DB_MAX_CONNECTIONS", 10)
+	viper.SetDefault("DB_MAX_IDLE_CONNECTIONS", 5)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", "1h")
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", "10m")
+	viper.SetDefault("DB_REPLICA_MAX_LAG", "5s")
+	viper.SetDefault("DB_REPLICA_CHECK_INTERVAL", "1s")
+	viper.SetDefault("DB_AUTO_MIGRATE", false)
+	viper.SetDefault("COMPANY_UNIQUE_KEYS", "name,registration_number")
 	viper.SetDefault("KAFKA_BROKER", "localhost:9092")
 	viper.SetDefault("KAFKA_TOPIC_COMPANY_EVENTS", "company_events")
+	viper.SetDefault("EVENT_ENCODING", "protobuf")
+	viper.SetDefault("CLOUDEVENTS_MODE", "off")
+	viper.SetDefault("CLOUDEVENTS_SOURCE", "/company-service")
+	viper.SetDefault("EVENT_SINKS", "kafka")
+	viper.SetDefault("EVENT_FILE", "company_events.jsonl")
+	viper.SetDefault("NATS_URL", "nats://localhost:4222")
+	viper.SetDefault("NATS_STREAM", "COMPANY_EVENTS")
+	viper.SetDefault("NATS_SUBJECT", "company.events")
+
+	viper.SetDefault("KAFKA_RETRY_MAX_ATTEMPTS", 5)
+	viper.SetDefault("KAFKA_RETRY_BACKOFF", "100ms")
+	viper.SetDefault("KAFKA_RETRY_MAX_BACKOFF", "2s")
+	viper.SetDefault("KAFKA_ATTEMPT_TIMEOUT", "5s")
+	viper.SetDefault("KAFKA_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("KAFKA_BREAKER_COOLDOWN", "30s")
+	viper.SetDefault("KAFKA_DEAD_LETTERS", "postgres")
+	viper.SetDefault("KAFKA_DEAD_LETTER_FILE", "kafka_dead_letters.jsonl")
+	viper.SetDefault("EVENT_PUBLISH_TIMEOUT", "5s")
+
+	viper.SetDefault("KAFKA_REQUIRED_ACKS", "all")
+	viper.SetDefault("KAFKA_COMPRESSION", "none")
+	viper.SetDefault("KAFKA_IDEMPOTENT", true)
+	viper.SetDefault("KAFKA_TLS_ENABLED", false)
+	viper.SetDefault("KAFKA_SASL_MECHANISM", "none")
+
+	viper.SetDefault("COMMANDS_ENABLED", false)
+	viper.SetDefault("KAFKA_TOPIC_COMPANY_COMMANDS", "company_commands")
+	viper.SetDefault("KAFKA_TOPIC_COMPANY_COMMANDS_DLQ", "company_commands_dlq")
+	viper.SetDefault("KAFKA_COMMANDS_GROUP_ID", "company-service")
+
+	viper.SetDefault("WEBHOOKS_ENABLED", false)
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_BACKOFF", "10s")
+	viper.SetDefault("WEBHOOK_MAX_BACKOFF", "1h")
+	viper.SetDefault("WEBHOOK_DISABLE_AFTER", 5)
+	viper.SetDefault("WEBHOOK_POLL_INTERVAL", "1s")
+	viper.SetDefault("WEBHOOK_ALLOW_HTTP", false)
+	viper.SetDefault("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false)
+
+	viper.SetDefault("KAFKA_TOPIC_COMPANY_SNAPSHOTS", "company_snapshots")
+	viper.SetDefault("KAFKA_SNAPSHOT_PARTITIONS", 3)
+	viper.SetDefault("KAFKA_SNAPSHOT_REPLICATION_FACTOR", 1)
+
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_BACKEND", "memory")
+	viper.SetDefault("RATE_LIMIT_RPS", 20)
+	viper.SetDefault("RATE_LIMIT_BURST", 40)
+	viper.SetDefault("RATE_LIMIT_METHOD_LIMITS", "Login=1:5")
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", "30s")
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", "15m")
+
+	viper.SetDefault("PURGE_INTERVAL", "1h")
+	viper.SetDefault("PURGE_RETENTION", "720h")
+
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_LEASE", "1m")
+
+	viper.SetDefault("CHANGE_FEED_POLL_INTERVAL", "5s")
+	viper.SetDefault("CHANGE_FEED_RETENTION", "168h")
 
 	err := viper.ReadInConfig() // Optional: Reads from .env if available
 	if err != nil {
@@ -31,8 +181,112 @@ func LoadConfig() (*Config, error) {
 		JWTSecret:               viper.GetString("JWT_SECRET"),
 		AppPort:                 viper.GetString("APP_PORT"),
 		DatabaseURL:             viper.GetString("DATABASE_URL"),
+		DBReplicaMaxLag:         viper.GetDuration("DB_REPLICA_MAX_LAG"),
+		DBReplicaCheckInterval:  viper.GetDuration("DB_REPLICA_CHECK_INTERVAL"),
+		DBMaxConnections:        viper.GetInt("DB_MAX_CONNECTIONS"),
+		DBMaxIdleConnections:    viper.GetInt("DB_MAX_IDLE_CONNECTIONS"),
+		DBConnMaxLifetime:       viper.GetDuration("DB_CONN_MAX_LIFETIME"),
+		DBConnMaxIdleTime:       viper.GetDuration("DB_CONN_MAX_IDLE_TIME"),
+		DBAutoMigrate:           viper.GetBool("DB_AUTO_MIGRATE"),
 		KafkaBroker:             viper.GetString("KAFKA_BROKER"),
 		KafkaTopicCompanyEvents: viper.GetString("KAFKA_TOPIC_COMPANY_EVENTS"),
+		EventEncoding:           viper.GetString("EVENT_ENCODING"),
+		CloudEventsMode:         viper.GetString("CLOUDEVENTS_MODE"),
+		CloudEventsSource:       viper.GetString("CLOUDEVENTS_SOURCE"),
+		EventFile:               viper.GetString("EVENT_FILE"),
+		NATSURL:                 viper.GetString("NATS_URL"),
+		NATSStream:              viper.GetString("NATS_STREAM"),
+		NATSSubject:             viper.GetString("NATS_SUBJECT"),
+
+		KafkaRetryMaxAttempts: viper.GetInt("KAFKA_RETRY_MAX_ATTEMPTS"),
+		KafkaRetryBackoff:     viper.GetDuration("KAFKA_RETRY_BACKOFF"),
+		KafkaRetryMaxBackoff:  viper.GetDuration("KAFKA_RETRY_MAX_BACKOFF"),
+		KafkaAttemptTimeout:   viper.GetDuration("KAFKA_ATTEMPT_TIMEOUT"),
+		KafkaBreakerThreshold: viper.GetInt("KAFKA_BREAKER_THRESHOLD"),
+		KafkaBreakerCooldown:  viper.GetDuration("KAFKA_BREAKER_COOLDOWN"),
+		KafkaDeadLetters:      viper.GetString("KAFKA_DEAD_LETTERS"),
+		KafkaDeadLetterFile:   viper.GetString("KAFKA_DEAD_LETTER_FILE"),
+		EventPublishTimeout:   viper.GetDuration("EVENT_PUBLISH_TIMEOUT"),
+
+		KafkaRequiredAcks:  viper.GetString("KAFKA_REQUIRED_ACKS"),
+		KafkaCompression:   viper.GetString("KAFKA_COMPRESSION"),
+		KafkaIdempotent:    viper.GetBool("KAFKA_IDEMPOTENT"),
+		KafkaTLSEnabled:    viper.GetBool("KAFKA_TLS_ENABLED"),
+		KafkaTLSCAFile:     viper.GetString("KAFKA_TLS_CA_FILE"),
+		KafkaTLSCertFile:   viper.GetString("KAFKA_TLS_CERT_FILE"),
+		KafkaTLSKeyFile:    viper.GetString("KAFKA_TLS_KEY_FILE"),
+		KafkaTLSSkipVerify: viper.GetBool("KAFKA_TLS_INSECURE_SKIP_VERIFY"),
+		KafkaSASLMechanism: viper.GetString("KAFKA_SASL_MECHANISM"),
+		KafkaSASLUsername:  viper.GetString("KAFKA_SASL_USERNAME"),
+		KafkaSASLPassword:  viper.GetString("KAFKA_SASL_PASSWORD"),
+
+		CommandsEnabled:       viper.GetBool("COMMANDS_ENABLED"),
+		KafkaTopicCommands:    viper.GetString("KAFKA_TOPIC_COMPANY_COMMANDS"),
+		KafkaTopicCommandsDLQ: viper.GetString("KAFKA_TOPIC_COMPANY_COMMANDS_DLQ"),
+		KafkaCommandsGroupID:  viper.GetString("KAFKA_COMMANDS_GROUP_ID"),
+
+		WebhooksEnabled:     viper.GetBool("WEBHOOKS_ENABLED"),
+		WebhookTimeout:      viper.GetDuration("WEBHOOK_TIMEOUT"),
+		WebhookMaxAttempts:  viper.GetInt("WEBHOOK_MAX_ATTEMPTS"),
+		WebhookBackoff:      viper.GetDuration("WEBHOOK_BACKOFF"),
+		WebhookMaxBackoff:   viper.GetDuration("WEBHOOK_MAX_BACKOFF"),
+		WebhookDisableAfter: viper.GetInt("WEBHOOK_DISABLE_AFTER"),
+		WebhookPollInterval: viper.GetDuration("WEBHOOK_POLL_INTERVAL"),
+		WebhookAllowHTTP:    viper.GetBool("WEBHOOK_ALLOW_HTTP"),
+		WebhookAllowPrivate: viper.GetBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS"),
+
+		KafkaTopicSnapshots:      viper.GetString("KAFKA_TOPIC_COMPANY_SNAPSHOTS"),
+		KafkaSnapshotPartitions:  viper.GetInt("KAFKA_SNAPSHOT_PARTITIONS"),
+		KafkaSnapshotReplication: viper.GetInt("KAFKA_SNAPSHOT_REPLICATION_FACTOR"),
+
+		RateLimitEnabled:      viper.GetBool("RATE_LIMIT_ENABLED"),
+		RateLimitBackend:      viper.GetString("RATE_LIMIT_BACKEND"),
+		RateLimitRPS:          viper.GetFloat64("RATE_LIMIT_RPS"),
+		RateLimitBurst:        viper.GetInt("RATE_LIMIT_BURST"),
+		RateLimitMethodLimits: viper.GetString("RATE_LIMIT_METHOD_LIMITS"),
+		LoginMaxFailures:      viper.GetInt("LOGIN_MAX_FAILURES"),
+		LoginLockoutBase:      viper.GetDuration("LOGIN_LOCKOUT_BASE"),
+		LoginLockoutMax:       viper.GetDuration("LOGIN_LOCKOUT_MAX"),
+
+		AuditSigningKey: viper.GetString("AUDIT_SIGNING_KEY"),
+
+		PurgeInterval:  viper.GetDuration("PURGE_INTERVAL"),
+		PurgeRetention: viper.GetDuration("PURGE_RETENTION"),
+
+		IdempotencyTTL:   viper.GetDuration("IDEMPOTENCY_TTL"),
+		IdempotencyLease: viper.GetDuration("IDEMPOTENCY_LEASE"),
+
+		ChangeFeedPollInterval: viper.GetDuration("CHANGE_FEED_POLL_INTERVAL"),
+		ChangeFeedRetention:    viper.GetDuration("CHANGE_FEED_RETENTION"),
+	}
+
+	// KAFKA_BROKERS lists several brokers; KAFKA_BROKER is kept for
+	// single-broker setups.
+	for _, broker := range strings.Split(viper.GetString("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			config.KafkaBrokers = append(config.KafkaBrokers, broker)
+		}
+	}
+	if len(config.KafkaBrokers) == 0 && config.KafkaBroker != "" {
+		config.KafkaBrokers = []string{config.KafkaBroker}
+	}
+
+	for _, replicaURL := range strings.Split(viper.GetString("DATABASE_REPLICA_URLS"), ",") {
+		if replicaURL = strings.TrimSpace(replicaURL); replicaURL != "" {
+			config.DatabaseReplicaURLs = append(config.DatabaseReplicaURLs, replicaURL)
+		}
+	}
+
+	for _, key := range strings.Split(viper.GetString("COMPANY_UNIQUE_KEYS"), ",") {
+		if key = strings.TrimSpace(strings.ToLower(key)); key != "" {
+			config.CompanyUniqueKeys = append(config.CompanyUniqueKeys, key)
+		}
+	}
+
+	for _, sink := range strings.Split(viper.GetString("EVENT_SINKS"), ",") {
+		if sink = strings.TrimSpace(strings.ToLower(sink)); sink != "" {
+			config.EventSinks = append(config.EventSinks, sink)
+		}
 	}
 
 	if config.JWTSecret == "" {
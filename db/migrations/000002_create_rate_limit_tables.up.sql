@@ -0,0 +1,11 @@
+CREATE TABLE rate_limit_buckets (
+                                    key TEXT PRIMARY KEY,
+                                    tokens DOUBLE PRECISION NOT NULL,
+                                    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE login_lockouts (
+                                key TEXT PRIMARY KEY,
+                                failures INT NOT NULL DEFAULT 0,
+                                locked_until TIMESTAMPTZ
+);
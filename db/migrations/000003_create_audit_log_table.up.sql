@@ -0,0 +1,24 @@
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           company_id BIGINT,
+                           actor TEXT NOT NULL,
+                           action VARCHAR(50) NOT NULL,
+                           before JSONB,
+                           after JSONB,
+                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_log_company_id_idx ON audit_log (company_id, id);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, id);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+-- The audit log is append-only: rows can never be changed or removed.
+CREATE FUNCTION audit_log_reject_modification() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_reject_modification();
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS audit_log_seq_idx;
+
+ALTER TABLE audit_log
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS seq;
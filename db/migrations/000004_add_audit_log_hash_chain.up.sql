@@ -0,0 +1,7 @@
+-- Entries written before this migration keep a NULL seq and are not part of the chain.
+ALTER TABLE audit_log
+    ADD COLUMN seq BIGINT,
+    ADD COLUMN prev_hash TEXT,
+    ADD COLUMN hash TEXT;
+
+CREATE UNIQUE INDEX audit_log_seq_idx ON audit_log (seq);
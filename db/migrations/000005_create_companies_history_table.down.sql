@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS companies_history ON companies;
+DROP FUNCTION IF EXISTS companies_record_history();
+DROP TABLE IF EXISTS companies_history;
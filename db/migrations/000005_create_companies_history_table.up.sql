@@ -0,0 +1,48 @@
+CREATE TABLE companies_history (
+                                   history_id BIGSERIAL PRIMARY KEY,
+                                   company_id INT NOT NULL,
+                                   version INT NOT NULL,
+                                   name VARCHAR(255) NOT NULL,
+                                   description TEXT,
+                                   employees INT,
+                                   registered BOOLEAN,
+                                   type VARCHAR(50),
+                                   valid_from TIMESTAMPTZ NOT NULL,
+                                   valid_to TIMESTAMPTZ,
+                                   UNIQUE (company_id, version)
+);
+
+CREATE INDEX companies_history_validity_idx ON companies_history (company_id, valid_from, valid_to);
+
+-- Every insert, update and delete on companies closes the current version and,
+-- unless the row was deleted, opens a new one.
+CREATE FUNCTION companies_record_history() RETURNS TRIGGER AS $$
+DECLARE
+    next_version INT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE companies_history
+        SET valid_to = NOW()
+        WHERE company_id = OLD.id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        SELECT COALESCE(MAX(version), 0) + 1 INTO next_version
+        FROM companies_history
+        WHERE company_id = NEW.id;
+
+        INSERT INTO companies_history (company_id, version, name, description, employees, registered, type, valid_from)
+        VALUES (NEW.id, next_version, NEW.name, NEW.description, NEW.employees, NEW.registered, NEW.type, NOW());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER companies_history
+    AFTER INSERT OR UPDATE OR DELETE ON companies
+    FOR EACH ROW EXECUTE FUNCTION companies_record_history();
+
+INSERT INTO companies_history (company_id, version, name, description, employees, registered, type, valid_from)
+SELECT id, 1, name, description, employees, registered, type, COALESCE(created_at, NOW())
+FROM companies;
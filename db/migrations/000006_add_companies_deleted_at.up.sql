@@ -0,0 +1,28 @@
+ALTER TABLE companies ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX companies_deleted_at_idx ON companies (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- A soft delete closes the current version without opening a new one and a
+-- restore opens a new version, so as_of reads do not see deleted companies.
+CREATE OR REPLACE FUNCTION companies_record_history() RETURNS TRIGGER AS $$
+DECLARE
+    next_version INT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE companies_history
+        SET valid_to = NOW()
+        WHERE company_id = OLD.id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.deleted_at IS NULL) THEN
+        SELECT COALESCE(MAX(version), 0) + 1 INTO next_version
+        FROM companies_history
+        WHERE company_id = NEW.id;
+
+        INSERT INTO companies_history (company_id, version, name, description, employees, registered, type, valid_from)
+        VALUES (NEW.id, next_version, NEW.name, NEW.description, NEW.employees, NEW.registered, NEW.type, NOW());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys (
+                                  principal TEXT NOT NULL,
+                                  key VARCHAR(255) NOT NULL,
+                                  method VARCHAR(100) NOT NULL,
+                                  request_hash CHAR(64) NOT NULL,
+                                  status VARCHAR(20) NOT NULL,
+                                  response_type TEXT,
+                                  response BYTEA,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  expires_at TIMESTAMPTZ NOT NULL,
+                                  PRIMARY KEY (principal, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
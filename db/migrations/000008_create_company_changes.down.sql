@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS companies_record_change ON companies;
+DROP FUNCTION IF EXISTS companies_record_change();
+DROP TABLE IF EXISTS company_changes;
@@ -0,0 +1,51 @@
+CREATE TABLE company_changes (
+                                 id BIGSERIAL PRIMARY KEY,
+                                 company_id BIGINT NOT NULL,
+                                 event_type VARCHAR(20) NOT NULL,
+                                 company JSONB NOT NULL,
+                                 created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX company_changes_created_at_idx ON company_changes (created_at);
+
+-- Every write to companies is recorded here and announced on the
+-- company_changes channel. The lock is the one audit entries are chained
+-- under, so ids are handed out in commit order and a watcher that reads
+-- past its last id never skips a change committed later.
+CREATE OR REPLACE FUNCTION companies_record_change() RETURNS TRIGGER AS $$
+DECLARE
+    change_id BIGINT;
+    event_type VARCHAR(20);
+    company companies;
+BEGIN
+    PERFORM pg_advisory_xact_lock(7250011);
+
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'CREATE';
+        company := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        event_type := 'PURGED';
+        company := OLD;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        event_type := 'DELETE';
+        company := NEW;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        event_type := 'RESTORE';
+        company := NEW;
+    ELSE
+        event_type := 'UPDATE';
+        company := NEW;
+    END IF;
+
+    INSERT INTO company_changes (company_id, event_type, company)
+    VALUES (company.id, event_type, to_jsonb(company))
+    RETURNING id INTO change_id;
+
+    PERFORM pg_notify('company_changes', change_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER companies_record_change
+    AFTER INSERT OR UPDATE OR DELETE ON companies
+    FOR EACH ROW EXECUTE FUNCTION companies_record_change();
@@ -0,0 +1,8 @@
+CREATE TABLE kafka_dead_letters (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    message_key TEXT NOT NULL,
+                                    value BYTEA NOT NULL,
+                                    headers JSONB NOT NULL DEFAULT '{}',
+                                    error TEXT NOT NULL,
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
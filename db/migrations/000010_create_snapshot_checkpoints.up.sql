@@ -0,0 +1,6 @@
+CREATE TABLE snapshot_checkpoints (
+                                      name TEXT PRIMARY KEY,
+                                      last_id BIGINT NOT NULL DEFAULT 0,
+                                      completed_at TIMESTAMPTZ,
+                                      updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
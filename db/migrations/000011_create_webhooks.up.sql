@@ -0,0 +1,40 @@
+CREATE TABLE webhook_subscriptions (
+                                       id BIGSERIAL PRIMARY KEY,
+                                       url TEXT NOT NULL,
+                                       event_types TEXT[] NOT NULL DEFAULT '{}',
+                                       secret TEXT NOT NULL,
+                                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                                       consecutive_failures INT NOT NULL DEFAULT 0,
+                                       disabled_at TIMESTAMPTZ,
+                                       disabled_reason TEXT NOT NULL DEFAULT '',
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+                                    event_id TEXT NOT NULL,
+                                    event_type VARCHAR(20) NOT NULL,
+                                    company_id BIGINT NOT NULL,
+                                    payload BYTEA NOT NULL,
+                                    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+                                    attempts INT NOT NULL DEFAULT 0,
+                                    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                    last_error TEXT NOT NULL DEFAULT '',
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id);
+
+CREATE TABLE webhook_attempts (
+                                  id BIGSERIAL PRIMARY KEY,
+                                  delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+                                  attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  status_code INT NOT NULL DEFAULT 0,
+                                  error TEXT NOT NULL DEFAULT '',
+                                  duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX webhook_attempts_delivery_idx ON webhook_attempts (delivery_id, id);
@@ -0,0 +1,27 @@
+CREATE OR REPLACE FUNCTION companies_record_history() RETURNS TRIGGER AS $$
+DECLARE
+    next_version INT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE companies_history
+        SET valid_to = NOW()
+        WHERE company_id = OLD.id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.deleted_at IS NULL) THEN
+        SELECT COALESCE(MAX(version), 0) + 1 INTO next_version
+        FROM companies_history
+        WHERE company_id = NEW.id;
+
+        INSERT INTO companies_history (company_id, version, name, description, employees, registered, type, valid_from)
+        VALUES (NEW.id, next_version, NEW.name, NEW.description, NEW.employees, NEW.registered, NEW.type, NOW());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS companies_registration_number_idx;
+DROP INDEX IF EXISTS companies_lower_name_idx;
+ALTER TABLE companies_history DROP COLUMN IF EXISTS registration_number;
+ALTER TABLE companies DROP COLUMN IF EXISTS registration_number;
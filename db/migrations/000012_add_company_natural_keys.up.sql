@@ -0,0 +1,33 @@
+ALTER TABLE companies ADD COLUMN registration_number VARCHAR(64);
+ALTER TABLE companies_history ADD COLUMN registration_number VARCHAR(64);
+
+-- Uniqueness is checked by the service, which can be configured to enforce
+-- it per key, so these indexes serve the lookups rather than constrain them.
+CREATE INDEX companies_lower_name_idx ON companies (lower(name)) WHERE deleted_at IS NULL;
+CREATE INDEX companies_registration_number_idx ON companies (registration_number)
+    WHERE deleted_at IS NULL AND registration_number IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION companies_record_history() RETURNS TRIGGER AS $$
+DECLARE
+    next_version INT;
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE companies_history
+        SET valid_to = NOW()
+        WHERE company_id = OLD.id AND valid_to IS NULL;
+    END IF;
+
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.deleted_at IS NULL) THEN
+        SELECT COALESCE(MAX(version), 0) + 1 INTO next_version
+        FROM companies_history
+        WHERE company_id = NEW.id;
+
+        INSERT INTO companies_history (company_id, version, name, description, employees, registered, type,
+                                       registration_number, valid_from)
+        VALUES (NEW.id, next_version, NEW.name, NEW.description, NEW.employees, NEW.registered, NEW.type,
+                NEW.registration_number, NOW());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS companies_set_updated_at ON companies;
+DROP FUNCTION IF EXISTS companies_set_updated_at();
+
+DROP INDEX IF EXISTS companies_updated_at_idx;
+DROP INDEX IF EXISTS companies_created_at_idx;
+
+ALTER TABLE companies
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP NOT NULL;
@@ -0,0 +1,34 @@
+-- Backfill without the history and change feed triggers, which would
+-- otherwise record the backfill as a change to every affected company.
+ALTER TABLE companies DISABLE TRIGGER companies_history;
+ALTER TABLE companies DISABLE TRIGGER companies_record_change;
+
+UPDATE companies
+SET created_at = COALESCE(created_at, NOW()),
+    updated_at = COALESCE(updated_at, created_at, NOW())
+WHERE created_at IS NULL OR updated_at IS NULL;
+
+ALTER TABLE companies ENABLE TRIGGER companies_history;
+ALTER TABLE companies ENABLE TRIGGER companies_record_change;
+
+ALTER TABLE companies
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX companies_created_at_idx ON companies (created_at, id);
+CREATE INDEX companies_updated_at_idx ON companies (updated_at, id);
+
+-- Any write that changes a company, soft deletes and restores included,
+-- moves updated_at to the time of its transaction.
+CREATE FUNCTION companies_set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD THEN
+        NEW.updated_at := NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER companies_set_updated_at
+    BEFORE UPDATE ON companies
+    FOR EACH ROW EXECUTE FUNCTION companies_set_updated_at();
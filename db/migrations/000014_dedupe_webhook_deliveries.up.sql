@@ -0,0 +1,9 @@
+-- An event is delivered to a subscription at most once, however often it is
+-- published (replayed dead letters republish events).
+DELETE FROM webhook_deliveries d
+USING webhook_deliveries earlier
+WHERE earlier.subscription_id = d.subscription_id
+  AND earlier.event_id = d.event_id
+  AND earlier.id < d.id;
+
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries (subscription_id, event_id);
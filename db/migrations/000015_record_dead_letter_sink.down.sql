@@ -0,0 +1 @@
+ALTER TABLE kafka_dead_letters DROP COLUMN sink;
@@ -0,0 +1,3 @@
+-- Dead letters added before this migration failed on any of the sinks and
+-- are replayed to all of them.
+ALTER TABLE kafka_dead_letters ADD COLUMN sink TEXT NOT NULL DEFAULT '';
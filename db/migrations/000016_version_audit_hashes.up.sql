@@ -0,0 +1,3 @@
+-- Existing entries were hashed in format 1; new entries record the format
+-- they are written in.
+ALTER TABLE audit_log ADD COLUMN hash_version SMALLINT NOT NULL DEFAULT 1;
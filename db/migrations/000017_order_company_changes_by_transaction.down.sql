@@ -0,0 +1,41 @@
+-- Restores the trigger of 000008, which orders changes by id under the audit
+-- chain lock.
+CREATE OR REPLACE FUNCTION companies_record_change() RETURNS TRIGGER AS $$
+DECLARE
+    change_id BIGINT;
+    event_type VARCHAR(20);
+    company companies;
+BEGIN
+    PERFORM pg_advisory_xact_lock(7250011);
+
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'CREATE';
+        company := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        event_type := 'PURGED';
+        company := OLD;
+    ELSIF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        event_type := 'DELETE';
+        company := NEW;
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        event_type := 'RESTORE';
+        company := NEW;
+    ELSE
+        event_type := 'UPDATE';
+        company := NEW;
+    END IF;
+
+    INSERT INTO company_changes (company_id, event_type, company)
+    VALUES (company.id, event_type, to_jsonb(company))
+    RETURNING id INTO change_id;
+
+    PERFORM pg_notify('company_changes', change_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS company_changes_position_idx;
+
+ALTER TABLE company_changes
+    DROP COLUMN previous,
+    DROP COLUMN txid;
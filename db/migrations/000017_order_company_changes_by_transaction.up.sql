@@ -0,0 +1,47 @@
+-- Changes used to take the audit chain lock so that ids were handed out in
+-- commit order, which serialised every write to companies. They now record
+-- the transaction that made them instead: a watcher reads only the changes of
+-- transactions older than every running one, in (txid, id) order, so a change
+-- that commits late still comes after the changes already read. Changes
+-- recorded under the lock keep txid 0 and their id order.
+ALTER TABLE company_changes
+    ADD COLUMN txid BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN previous JSONB;
+
+CREATE INDEX company_changes_position_idx ON company_changes (txid, id);
+
+-- previous holds the row before an UPDATE, so filters on the company also
+-- match changes that move it out of the filter.
+CREATE OR REPLACE FUNCTION companies_record_change() RETURNS TRIGGER AS $$
+DECLARE
+    change_id BIGINT;
+    event_type VARCHAR(20);
+    company companies;
+    previous JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'CREATE';
+        company := NEW;
+    ELSIF TG_OP = 'DELETE' THEN
+        event_type := 'PURGED';
+        company := OLD;
+    ELSE
+        IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+            event_type := 'DELETE';
+        ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+            event_type := 'RESTORE';
+        ELSE
+            event_type := 'UPDATE';
+        END IF;
+        company := NEW;
+        previous := to_jsonb(OLD);
+    END IF;
+
+    INSERT INTO company_changes (company_id, event_type, company, previous, txid)
+    VALUES (company.id, event_type, to_jsonb(company), previous, pg_current_xact_id()::text::bigint)
+    RETURNING id INTO change_id;
+
+    PERFORM pg_notify('company_changes', change_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
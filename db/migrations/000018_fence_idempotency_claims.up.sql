@@ -0,0 +1,3 @@
+-- Each claim of a key gets a fresh token; only the request holding the
+-- current token may renew, complete or release the key.
+ALTER TABLE idempotency_keys ADD COLUMN claim_token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS login_lockouts_forget_at_idx;
+ALTER TABLE login_lockouts DROP COLUMN forget_at;
@@ -0,0 +1,6 @@
+-- Failures are forgotten once a key has neither failed nor been locked out
+-- for LOGIN_LOCKOUT_MAX. Current lockouts keep their count for the default
+-- of 15 minutes after they end.
+ALTER TABLE login_lockouts ADD COLUMN forget_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE login_lockouts SET forget_at = locked_until + INTERVAL '15 minutes' WHERE locked_until > NOW();
+CREATE INDEX login_lockouts_forget_at_idx ON login_lockouts (forget_at);
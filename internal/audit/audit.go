@@ -0,0 +1,312 @@
+package audit
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/db"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	ActionCompanyCreate  = "COMPANY_CREATE"
+	ActionCompanyUpdate  = "COMPANY_UPDATE"
+	ActionCompanyDelete  = "COMPANY_DELETE"
+	ActionCompanyRestore = "COMPANY_RESTORE"
+	ActionCompanyPurge   = "COMPANY_PURGE"
+	ActionLoginSuccess   = "LOGIN_SUCCESS"
+	ActionLoginFailure   = "LOGIN_FAILURE"
+)
+
+type Entry struct {
+	ID          int64
+	Seq         int64
+	CompanyID   int64
+	Actor       string
+	Action      string
+	Before      *proto.Company
+	After       *proto.Company
+	CreatedAt   time.Time
+	PrevHash    string
+	Hash        string
+	HashVersion int // Format the snapshots are stored and hashed in
+}
+
+// HashVersion is the format new entries are written in. Version 1 stored and
+// hashed the encoding/json form of the generated proto.Company, which changes
+// whenever the proto does; version 2 uses the fixed fields of snapshot. Each
+// entry keeps the version it was written with, so older entries still verify.
+const HashVersion = 2
+
+type actorKey struct{}
+
+// ContextWithActor makes ActorFromContext report actor, for changes made
+// outside an authenticated RPC such as CLI commands and background workers.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		return UserActor(userID)
+	}
+	return "anonymous"
+}
+
+func UserActor(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// SystemActor identifies changes made by the service itself, such as scheduled jobs.
+func SystemActor(name string) string {
+	return "system:" + name
+}
+
+// Record appends entry to the audit chain inside the transaction carried by
+// ctx, which must be the transaction of the change being audited. The chain
+// head is locked until the transaction ends so entries are linked in commit
+// order.
+func Record(ctx context.Context, entry Entry) error {
+	tx, ok := db.TxFromContext(ctx)
+	if !ok {
+		return errors.New("audit entries must be recorded in the transaction of the change")
+	}
+	entry.HashVersion = HashVersion
+	before, err := marshalSnapshot(entry.HashVersion, entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalSnapshot(entry.HashVersion, entry.After)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", chainLockID); err != nil {
+		return fmt.Errorf("could not lock audit chain: %w", err)
+	}
+	head, err := Head(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	entry.Seq = head.Seq + 1
+	entry.PrevHash = head.Hash
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if entry.Hash, err = entry.ComputeHash(); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_log (seq, company_id, actor, action, before, after, created_at, prev_hash, hash, hash_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = tx.ExecContext(ctx, query, entry.Seq, nullableID(entry.CompanyID), entry.Actor, entry.Action, before, after,
+		entry.CreatedAt, entry.PrevHash, entry.Hash, entry.HashVersion)
+	if err != nil {
+		return fmt.Errorf("could not write audit entry: %w", err)
+	}
+	return nil
+}
+
+type Filter struct {
+	CompanyID int64
+	Actor     string
+	From      time.Time
+	To        time.Time
+	AfterID   int64
+	Limit     int
+}
+
+func List(ctx context.Context, db *sql.DB, filter Filter) ([]Entry, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	addCondition("id > $%d", filter.AfterID)
+	if filter.CompanyID != 0 {
+		addCondition("company_id = $%d", filter.CompanyID)
+	}
+	if filter.Actor != "" {
+		addCondition("actor = $%d", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%d", filter.To)
+	}
+	args = append(args, filter.Limit)
+
+	query := fmt.Sprintf(`
+		SELECT `+entryColumns+`
+		FROM audit_log
+		WHERE %s
+		ORDER BY id
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list audit entries: %v", err)
+	}
+	return scanEntries(rows)
+}
+
+const entryColumns = "id, COALESCE(seq, 0), COALESCE(company_id, 0), actor, action, before, after, created_at, " +
+	"COALESCE(prev_hash, ''), COALESCE(hash, ''), hash_version"
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var before, after []byte
+		err := rows.Scan(&entry.ID, &entry.Seq, &entry.CompanyID, &entry.Actor, &entry.Action, &before, &after,
+			&entry.CreatedAt, &entry.PrevHash, &entry.Hash, &entry.HashVersion)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan audit entry: %v", err)
+		}
+		if entry.Before, err = unmarshalSnapshot(entry.HashVersion, before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = unmarshalSnapshot(entry.HashVersion, after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// snapshot is the state of a company as stored and hashed by version 2
+// entries. Its fields are listed here instead of taken from proto.Company so
+// that renaming or adding proto fields does not change existing entries; a
+// field added to the proto is audited once a new version includes it.
+type snapshot struct {
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Employees          int32      `json:"employees"`
+	Registered         bool       `json:"registered"`
+	Type               string     `json:"type"`
+	RegistrationNumber string     `json:"registration_number"`
+	DeletedAt          *time.Time `json:"deleted_at"`
+	CreatedAt          *time.Time `json:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at"`
+}
+
+func newSnapshot(company *proto.Company) *snapshot {
+	if company == nil {
+		return nil
+	}
+	return &snapshot{
+		ID:                 company.Id,
+		Name:               company.Name,
+		Description:        company.Description,
+		Employees:          company.Employees,
+		Registered:         company.Registered,
+		Type:               company.Type,
+		RegistrationNumber: company.RegistrationNumber,
+		DeletedAt:          snapshotTime(company.DeletedAt),
+		CreatedAt:          snapshotTime(company.CreatedAt),
+		UpdatedAt:          snapshotTime(company.UpdatedAt),
+	}
+}
+
+func (s *snapshot) company() *proto.Company {
+	return &proto.Company{
+		Id:                 s.ID,
+		Name:               s.Name,
+		Description:        s.Description,
+		Employees:          s.Employees,
+		Registered:         s.Registered,
+		Type:               s.Type,
+		RegistrationNumber: s.RegistrationNumber,
+		DeletedAt:          protoTime(s.DeletedAt),
+		CreatedAt:          protoTime(s.CreatedAt),
+		UpdatedAt:          protoTime(s.UpdatedAt),
+	}
+}
+
+func snapshotTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime().UTC()
+	return &t
+}
+
+func protoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// encodeSnapshot returns the JSON form of company in the given version,
+// "null" for no company.
+func encodeSnapshot(version int, company *proto.Company) ([]byte, error) {
+	var value interface{}
+	switch version {
+	case 1:
+		value = company
+	case 2:
+		value = newSnapshot(company)
+	default:
+		return nil, fmt.Errorf("unknown audit hash version %d", version)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal audit snapshot: %v", err)
+	}
+	return data, nil
+}
+
+func marshalSnapshot(version int, company *proto.Company) (interface{}, error) {
+	if company == nil {
+		return nil, nil
+	}
+	return encodeSnapshot(version, company)
+}
+
+func unmarshalSnapshot(version int, data []byte) (*proto.Company, error) {
+	if data == nil {
+		return nil, nil
+	}
+	switch version {
+	case 1:
+		var company proto.Company
+		if err := json.Unmarshal(data, &company); err != nil {
+			return nil, fmt.Errorf("could not unmarshal audit snapshot: %v", err)
+		}
+		return &company, nil
+	case 2:
+		var s snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("could not unmarshal audit snapshot: %v", err)
+		}
+		return s.company(), nil
+	default:
+		return nil, fmt.Errorf("unknown audit hash version %d", version)
+	}
+}
+
+func nullableID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chainLockID is the advisory lock serialising appends to the audit chain.
+const chainLockID = 7_250_011
+
+const verifyBatchSize = 1000
+
+// ComputeHash hashes the entry together with the hash of its predecessor, so
+// changing or removing any entry breaks every link after it. The entry is
+// hashed in the format of its HashVersion.
+func (e Entry) ComputeHash() (string, error) {
+	before, err := encodeSnapshot(e.HashVersion, e.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := encodeSnapshot(e.HashVersion, e.After)
+	if err != nil {
+		return "", err
+	}
+
+	fields := []string{
+		e.PrevHash,
+		strconv.FormatInt(e.Seq, 10),
+		strconv.FormatInt(e.CompanyID, 10),
+		e.Actor,
+		e.Action,
+		string(before),
+		string(after),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if e.HashVersion > 1 {
+		fields = append([]string{strconv.Itoa(e.HashVersion)}, fields...)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type ChainHead struct {
+	Seq  int64
+	Hash string
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func Head(ctx context.Context, db querier) (ChainHead, error) {
+	var head ChainHead
+	err := db.QueryRowContext(ctx,
+		"SELECT seq, hash FROM audit_log WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1",
+	).Scan(&head.Seq, &head.Hash)
+	if err == sql.ErrNoRows {
+		return ChainHead{}, nil
+	}
+	if err != nil {
+		return ChainHead{}, fmt.Errorf("could not read audit chain head: %v", err)
+	}
+	return head, nil
+}
+
+type Verification struct {
+	Valid           bool
+	EntriesVerified int64
+	FirstBrokenSeq  int64
+	Reason          string
+	Head            ChainHead
+}
+
+// Verify walks the whole chain and reports the first entry whose sequence,
+// link to its predecessor or own hash does not match.
+func Verify(ctx context.Context, db *sql.DB) (Verification, error) {
+	var result Verification
+	for {
+		rows, err := db.QueryContext(ctx, `
+			SELECT `+entryColumns+`
+			FROM audit_log
+			WHERE seq > $1
+			ORDER BY seq
+			LIMIT $2
+		`, result.Head.Seq, verifyBatchSize)
+		if err != nil {
+			return Verification{}, fmt.Errorf("could not read audit chain: %v", err)
+		}
+		entries, err := scanEntries(rows)
+		if err != nil {
+			return Verification{}, err
+		}
+
+		for _, entry := range entries {
+			if reason := checkLink(entry, result.Head); reason != "" {
+				result.FirstBrokenSeq = result.Head.Seq + 1
+				result.Reason = reason
+				return result, nil
+			}
+			result.Head = ChainHead{Seq: entry.Seq, Hash: entry.Hash}
+			result.EntriesVerified++
+		}
+
+		if len(entries) < verifyBatchSize {
+			result.Valid = true
+			return result, nil
+		}
+	}
+}
+
+func checkLink(entry Entry, prev ChainHead) string {
+	if entry.Seq != prev.Seq+1 {
+		return fmt.Sprintf("entry %d is missing", prev.Seq+1)
+	}
+	if entry.PrevHash != prev.Hash {
+		return fmt.Sprintf("entry %d does not link to the hash of entry %d", entry.Seq, prev.Seq)
+	}
+	hash, err := entry.ComputeHash()
+	if err != nil {
+		return err.Error()
+	}
+	if hash != entry.Hash {
+		return fmt.Sprintf("entry %d has been modified", entry.Seq)
+	}
+	return ""
+}
+
+// Checkpoint is a signed statement of the chain head at a point in time.
+// Auditors keep checkpoints outside the database; if the chain is later
+// rewritten, the entry at Seq will no longer have Hash.
+type Checkpoint struct {
+	Seq       int64     `json:"seq"`
+	Hash      string    `json:"hash"`
+	SignedAt  time.Time `json:"signed_at"`
+	PublicKey string    `json:"public_key"`
+	Signature string    `json:"signature"`
+}
+
+func (c Checkpoint) payload() []byte {
+	return []byte(fmt.Sprintf("%d\n%s\n%s", c.Seq, c.Hash, c.SignedAt.UTC().Format(time.RFC3339Nano)))
+}
+
+func SignCheckpoint(key ed25519.PrivateKey, head ChainHead, signedAt time.Time) Checkpoint {
+	checkpoint := Checkpoint{
+		Seq:       head.Seq,
+		Hash:      head.Hash,
+		SignedAt:  signedAt.UTC(),
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpoint.payload()))
+	return checkpoint
+}
+
+// VerifyCheckpoint checks that the checkpoint was signed by publicKey and that
+// the chain still contains the checkpointed entry unchanged.
+func VerifyCheckpoint(ctx context.Context, db querier, publicKey ed25519.PublicKey, checkpoint Checkpoint) error {
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint signature encoding: %v", err)
+	}
+	if !ed25519.Verify(publicKey, checkpoint.payload(), signature) {
+		return fmt.Errorf("checkpoint signature is not valid")
+	}
+
+	var hash string
+	err = db.QueryRowContext(ctx, "SELECT hash FROM audit_log WHERE seq = $1", checkpoint.Seq).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("checkpointed entry %d is missing", checkpoint.Seq)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read checkpointed entry: %v", err)
+	}
+	if hash != checkpoint.Hash {
+		return fmt.Errorf("checkpointed entry %d has been modified", checkpoint.Seq)
+	}
+	return nil
+}
+
+// ParseSigningKey decodes a base64 Ed25519 seed or private key.
+func ParseSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit signing key encoding: %v", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("audit signing key must be a %d byte seed or %d byte private key", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
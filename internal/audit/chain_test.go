@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"company-service/proto"
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var chainColumns = []string{"id", "seq", "company_id", "actor", "action", "before", "after", "created_at", "prev_hash", "hash", "hash_version"}
+
+func chainedEntries(t *testing.T, n int) []Entry {
+	return chainedEntriesFrom(t, 1, n, HashVersion)
+}
+
+func chainedEntriesFrom(t *testing.T, from, n, version int, prev ...Entry) []Entry {
+	var entries []Entry
+	prevHash := ""
+	if len(prev) > 0 {
+		prevHash = prev[len(prev)-1].Hash
+	}
+	for i := from; i < from+n; i++ {
+		entry := Entry{
+			ID:          int64(i),
+			Seq:         int64(i),
+			CompanyID:   1,
+			Actor:       "user:1",
+			Action:      ActionCompanyUpdate,
+			Before:      &proto.Company{Id: 1, Name: "Before <Co>"},
+			After:       &proto.Company{Id: 1, Name: "After & Co", Employees: int32(i)},
+			CreatedAt:   time.Date(2024, 1, 1, 0, 0, i, 1000, time.UTC),
+			PrevHash:    prevHash,
+			HashVersion: version,
+		}
+		hash, err := entry.ComputeHash()
+		assert.NoError(t, err)
+		entry.Hash = hash
+		prevHash = hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func chainRows(t *testing.T, entries []Entry) *sqlmock.Rows {
+	rows := sqlmock.NewRows(chainColumns)
+	for _, e := range entries {
+		before, err := marshalSnapshot(e.HashVersion, e.Before)
+		assert.NoError(t, err)
+		after, err := marshalSnapshot(e.HashVersion, e.After)
+		assert.NoError(t, err)
+		rows.AddRow(e.ID, e.Seq, e.CompanyID, e.Actor, e.Action, before, after, e.CreatedAt.In(time.Local), e.PrevHash, e.Hash, e.HashVersion)
+	}
+	return rows
+}
+
+func TestVerifyValidChain(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	entries := chainedEntries(t, 3)
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(0), verifyBatchSize).WillReturnRows(chainRows(t, entries))
+
+	result, err := Verify(context.Background(), db)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(3), result.EntriesVerified)
+	assert.Equal(t, entries[2].Hash, result.Head.Hash)
+}
+
+func TestVerifyChainAcrossHashVersions(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	entries := chainedEntriesFrom(t, 1, 2, 1)
+	entries = append(entries, chainedEntriesFrom(t, 3, 2, HashVersion, entries...)...)
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(0), verifyBatchSize).WillReturnRows(chainRows(t, entries))
+
+	result, err := Verify(context.Background(), db)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(4), result.EntriesVerified)
+}
+
+// The version 2 format must never change: entries already written would no
+// longer verify. Changes to it belong in a new version.
+func TestComputeHashVersion2IsStable(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := Entry{
+		Seq:       7,
+		CompanyID: 1,
+		Actor:     "user:1",
+		Action:    ActionCompanyUpdate,
+		Before:    &proto.Company{Id: 1, Name: "Before Co", CreatedAt: timestamppb.New(created)},
+		After: &proto.Company{Id: 1, Name: "After Co", Employees: 5, Registered: true, Type: "LLC",
+			RegistrationNumber: "HRB 1", CreatedAt: timestamppb.New(created), UpdatedAt: timestamppb.New(created.Add(time.Hour))},
+		CreatedAt:   created.Add(time.Hour),
+		PrevHash:    "prev-hash",
+		HashVersion: 2,
+	}
+
+	hash, err := entry.ComputeHash()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "6bb964dae56d3f98ea8021a007643655ea6755707fcab6514e558b4d985b9147", hash)
+}
+
+func TestVerifyReportsFirstBrokenLink(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	entries := chainedEntries(t, 3)
+	entries[1].After.Name = "Tampered Co"
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(0), verifyBatchSize).WillReturnRows(chainRows(t, entries))
+
+	result, err := Verify(context.Background(), db)
+
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(2), result.FirstBrokenSeq)
+	assert.Equal(t, "entry 2 has been modified", result.Reason)
+}
+
+func TestVerifyReportsMissingEntry(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	entries := chainedEntries(t, 3)
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(0), verifyBatchSize).
+		WillReturnRows(chainRows(t, []Entry{entries[0], entries[2]}))
+
+	result, err := Verify(context.Background(), db)
+
+	assert.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(2), result.FirstBrokenSeq)
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	checkpoint := SignCheckpoint(key, ChainHead{Seq: 5, Hash: "abc"}, time.Now())
+
+	mock.ExpectQuery("SELECT hash FROM audit_log WHERE seq = \\$1").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("abc"))
+	assert.NoError(t, VerifyCheckpoint(context.Background(), db, key.Public().(ed25519.PublicKey), checkpoint))
+
+	checkpoint.Seq = 6
+	assert.EqualError(t, VerifyCheckpoint(context.Background(), db, key.Public().(ed25519.PublicKey), checkpoint),
+		"checkpoint signature is not valid")
+}
+
+func TestRecordRequiresTransaction(t *testing.T) {
+	err := Record(context.Background(), Entry{Actor: "user:1", Action: ActionLoginSuccess})
+	assert.Error(t, err)
+}
@@ -10,6 +10,17 @@ import (
 	"time"
 )
 
+type userIDKey struct{}
+
+func ContextWithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(int64)
+	return userID, ok
+}
+
 type AuthService struct {
 	JWTSecret []byte
 }
@@ -49,6 +60,30 @@ func (auth *AuthService) JWTInterceptor(
 		return handler(ctx, req)
 	}
 
+	ctx, err := auth.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (auth *AuthService) JWTStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+
+	ctx, err := auth.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+func (auth *AuthService) authenticate(ctx context.Context) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, errors.New("no metadata in context")
@@ -62,10 +97,26 @@ func (auth *AuthService) JWTInterceptor(
 		return nil, errors.New("authorization token is missing")
 	}
 
-	_, err := auth.ValidateToken(tokenStr)
+	token, err := auth.ValidateToken(tokenStr)
 	if err != nil {
 		return nil, errors.New("invalid token: " + err.Error())
 	}
 
-	return handler(ctx, req)
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if userID, ok := claims["user_id"].(float64); ok {
+			ctx = ContextWithUserID(ctx, int64(userID))
+		}
+	}
+	return ctx, nil
+}
+
+// contextStream overrides the context of a server stream so handlers see
+// values added by interceptors.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
 }
@@ -0,0 +1,294 @@
+package changefeed
+
+import (
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Channel is the Postgres notification channel the companies_record_change
+// trigger announces new changes on.
+const Channel = "company_changes"
+
+// Change is a write to the companies table as recorded by the
+// companies_record_change trigger.
+type Change struct {
+	ID        int64
+	TxID      int64 // Transaction that made the change
+	EventType string
+	Company   *proto.Company
+	Previous  *proto.Company // The company before an UPDATE, DELETE or RESTORE
+	CreatedAt time.Time
+}
+
+func (c Change) Position() Position {
+	return Position{TxID: c.TxID, ID: c.ID}
+}
+
+// Position orders changes by the transaction that made them, then by id.
+// Ids alone are handed out before commit, so a change with a lower id can
+// commit after one with a higher id; transactions are only read once they
+// are older than every running one, after which no earlier position can
+// appear.
+type Position struct {
+	TxID int64
+	ID   int64
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.TxID, p.ID)
+}
+
+// ParsePosition parses the form returned by String. A bare id is a position
+// of a change recorded before changes had a transaction.
+func ParsePosition(s string) (Position, error) {
+	txID, id, found := strings.Cut(s, ":")
+	if !found {
+		txID, id = "0", s
+	}
+	var p Position
+	var err error
+	if p.TxID, err = strconv.ParseInt(txID, 10, 64); err != nil || p.TxID < 0 {
+		return Position{}, fmt.Errorf("invalid change position %q", s)
+	}
+	if p.ID, err = strconv.ParseInt(id, 10, 64); err != nil || p.ID < 0 {
+		return Position{}, fmt.Errorf("invalid change position %q", s)
+	}
+	return p, nil
+}
+
+// horizon is the oldest transaction still running. Changes of older
+// transactions are final. A transaction that stays open, such as a session
+// left idle in one, holds the horizon back and stalls every watcher until it
+// ends; idle_in_transaction_session_timeout on the database bounds the stall.
+const horizon = "pg_snapshot_xmin(pg_current_snapshot())::text::bigint"
+
+// Filter narrows the changes returned by Since; zero values match everything.
+// Type and NameContains match a change when either the company or its
+// previous state matches, so a watcher also sees a company leave the filter.
+type Filter struct {
+	EventTypes   []string
+	CompanyIDs   []int64
+	Type         string
+	NameContains string
+	Committed    string // A snapshot from Start; changes of transactions already committed in it are skipped
+}
+
+// Feed reads the company_changes table and wakes watchers when new changes
+// are committed, on any replica.
+type Feed struct {
+	DB *sql.DB
+
+	mu   sync.Mutex
+	wake chan struct{}
+}
+
+func NewFeed(db *sql.DB) *Feed {
+	return &Feed{DB: db, wake: make(chan struct{})}
+}
+
+// Changed returns a channel that is closed on the next change notification.
+// Take it before reading the feed so a change committed in between is not
+// missed.
+func (f *Feed) Changed() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.wake
+}
+
+// Notify wakes every watcher waiting on Changed.
+func (f *Feed) Notify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.wake)
+	f.wake = make(chan struct{})
+}
+
+// Listen relays notifications on Channel to watchers until ctx is done. It
+// also wakes them every pollInterval, so changes whose notification was lost
+// while the listener reconnected are still picked up.
+func (f *Feed) Listen(ctx context.Context, dsn string, pollInterval time.Duration) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Change feed listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(Channel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-listener.Notify:
+			// A nil notification means the connection was re-established.
+			f.Notify()
+		case <-ticker.C:
+			f.Notify()
+		}
+	}
+}
+
+// Start returns the position a new watcher starts after and the snapshot to
+// set as its Filter.Committed. The position is the oldest running
+// transaction, so transactions after it may have committed already; the
+// snapshot skips those, leaving only changes of running and later
+// transactions.
+func (f *Feed) Start(ctx context.Context) (Position, string, error) {
+	var p Position
+	var snapshot string
+	err := f.DB.QueryRowContext(ctx, "SELECT pg_snapshot_xmin(s)::text::bigint, s::text FROM pg_current_snapshot() AS s").
+		Scan(&p.TxID, &snapshot)
+	return p, snapshot, err
+}
+
+// Kept reports whether the change at p is still kept. Once it has been
+// pruned, changes after it may have been pruned too.
+func (f *Feed) Kept(ctx context.Context, p Position) (bool, error) {
+	var kept bool
+	err := f.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM company_changes WHERE id = $1)", p.ID).Scan(&kept)
+	return kept, err
+}
+
+// Since returns up to limit changes matching filter after the given
+// position, in position order. Changes of transactions that are not older
+// than every running one are left for a later call.
+func (f *Feed) Since(ctx context.Context, after Position, filter Filter, limit int) ([]Change, error) {
+	conditions := []string{"(txid, id) > ($1, $2)", "txid < " + horizon}
+	args := []interface{}{after.TxID, after.ID}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(filter.EventTypes) > 0 {
+		where("event_type = ANY($%d)", pq.Array(filter.EventTypes))
+	}
+	if len(filter.CompanyIDs) > 0 {
+		where("company_id = ANY($%d)", pq.Array(filter.CompanyIDs))
+	}
+	if filter.Type != "" {
+		where("(company->>'type' = $%[1]d OR previous->>'type' = $%[1]d)", filter.Type)
+	}
+	if filter.NameContains != "" {
+		where("(company->>'name' ILIKE '%%' || $%[1]d || '%%' OR previous->>'name' ILIKE '%%' || $%[1]d || '%%')", filter.NameContains)
+	}
+	if filter.Committed != "" {
+		where("NOT pg_visible_in_snapshot(txid::text::xid8, $%d::pg_snapshot)", filter.Committed)
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf("SELECT id, txid, event_type, company, previous, created_at FROM company_changes WHERE %s ORDER BY txid, id LIMIT $%d",
+		strings.Join(conditions, " AND "), len(args))
+	rows, err := f.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var change Change
+		var company, previous []byte
+		if err := rows.Scan(&change.ID, &change.TxID, &change.EventType, &company, &previous, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		if change.Company, err = unmarshalCompany(company); err != nil {
+			return nil, fmt.Errorf("change %d: %v", change.ID, err)
+		}
+		if previous != nil {
+			if change.Previous, err = unmarshalCompany(previous); err != nil {
+				return nil, fmt.Errorf("change %d: %v", change.ID, err)
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// Prune removes changes older than retention and returns how many were
+// removed.
+func (f *Feed) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	result, err := f.DB.ExecContext(ctx, "DELETE FROM company_changes WHERE created_at < $1", time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// companyRow is a companies row as serialised by to_jsonb.
+type companyRow struct {
+	ID                 int64      `json:"id"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Employees          int32      `json:"employees"`
+	Registered         bool       `json:"registered"`
+	Type               string     `json:"type"`
+	DeletedAt          *time.Time `json:"deleted_at"`
+	RegistrationNumber string     `json:"registration_number"`
+	CreatedAt          *time.Time `json:"created_at"`
+	UpdatedAt          *time.Time `json:"updated_at"`
+}
+
+func unmarshalCompany(data []byte) (*proto.Company, error) {
+	var row companyRow
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, err
+	}
+	company := &proto.Company{
+		Id:                 row.ID,
+		Name:               row.Name,
+		Description:        row.Description,
+		Employees:          row.Employees,
+		Registered:         row.Registered,
+		Type:               row.Type,
+		RegistrationNumber: row.RegistrationNumber,
+	}
+	if row.DeletedAt != nil {
+		company.DeletedAt = timestamppb.New(*row.DeletedAt)
+	}
+	if row.CreatedAt != nil {
+		company.CreatedAt = timestamppb.New(*row.CreatedAt)
+	}
+	if row.UpdatedAt != nil {
+		company.UpdatedAt = timestamppb.New(*row.UpdatedAt)
+	}
+	return company, nil
+}
+
+// RunPrune prunes changes older than retention every interval until ctx is
+// done.
+func (f *Feed) RunPrune(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := f.Prune(ctx, retention)
+			if err != nil {
+				log.Printf("Failed to prune change feed: %v", err)
+			}
+			if pruned > 0 {
+				log.Printf("Pruned %d changes older than %s", pruned, retention)
+			}
+		}
+	}
+}
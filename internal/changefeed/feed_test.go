@@ -0,0 +1,76 @@
+package changefeed
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSinceAppliesFilters(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, txid, event_type, company, previous, created_at FROM company_changes "+
+		"WHERE \\(txid, id\\) > \\(\\$1, \\$2\\) AND txid < pg_snapshot_xmin\\(pg_current_snapshot\\(\\)\\)::text::bigint "+
+		"AND event_type = ANY\\(\\$3\\) AND \\(company->>'type' = \\$4 OR previous->>'type' = \\$4\\) ORDER BY txid, id LIMIT \\$5").
+		WithArgs(int64(900), int64(7), sqlmock.AnyArg(), "LLC", 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "txid", "event_type", "company", "previous", "created_at"}).
+			AddRow(8, 901, "UPDATE", []byte(`{"id": 1, "name": "Test Co", "description": null, "employees": 10, "registered": true, "type": "LLC", "deleted_at": null}`),
+				[]byte(`{"id": 1, "name": "Test Co", "employees": 10, "registered": true, "type": "GmbH", "deleted_at": null}`), createdAt).
+			AddRow(9, 902, "DELETE", []byte(`{"id": 2, "name": "Other Co", "employees": 0, "registered": false, "type": "LLC", "deleted_at": "2024-05-01T10:00:00.123456+00:00"}`), nil, createdAt))
+
+	feed := NewFeed(db)
+	changes, err := feed.Since(context.Background(), Position{TxID: 900, ID: 7}, Filter{EventTypes: []string{"UPDATE", "DELETE"}, Type: "LLC"}, 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, Position{TxID: 901, ID: 8}, changes[0].Position())
+	assert.Equal(t, "Test Co", changes[0].Company.Name)
+	assert.Equal(t, int32(10), changes[0].Company.Employees)
+	assert.Equal(t, "GmbH", changes[0].Previous.Type)
+	assert.Nil(t, changes[0].Company.DeletedAt)
+	assert.Equal(t, "DELETE", changes[1].EventType)
+	assert.Nil(t, changes[1].Previous)
+	assert.Equal(t, int64(123456000), int64(changes[1].Company.DeletedAt.AsTime().Nanosecond()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParsePosition(t *testing.T) {
+	p, err := ParsePosition(Position{TxID: 901, ID: 8}.String())
+	assert.NoError(t, err)
+	assert.Equal(t, Position{TxID: 901, ID: 8}, p)
+
+	// Tokens handed out before changes recorded their transaction.
+	p, err = ParsePosition("42")
+	assert.NoError(t, err)
+	assert.Equal(t, Position{ID: 42}, p)
+
+	for _, invalid := range []string{"", "x", "1:", ":2", "-1:2", "1:2:3"} {
+		_, err := ParsePosition(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNotifyWakesWatchers(t *testing.T) {
+	feed := NewFeed(nil)
+
+	changed := feed.Changed()
+	select {
+	case <-changed:
+		t.Fatal("woken before any notification")
+	default:
+	}
+
+	feed.Notify()
+	<-changed
+
+	select {
+	case <-feed.Changed():
+		t.Fatal("new watchers must wait for the next notification")
+	default:
+	}
+}
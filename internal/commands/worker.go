@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/events"
+	"company-service/internal/idempotency"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	gproto "google.golang.org/protobuf/proto"
+)
+
+// Headers added to messages routed to the dead-letter topic.
+const (
+	HeaderError     = "dlq-error"
+	HeaderTopic     = "dlq-topic"
+	HeaderPartition = "dlq-partition"
+	HeaderOffset    = "dlq-offset"
+)
+
+const maxRetryBackoff = 30 * time.Second
+
+// Worker applies company commands read from a Kafka topic through the
+// company service, acting as Actor. A message's offset is committed only
+// once the command was applied or the message was dead-lettered; commands
+// that fail for a transient reason are retried until they succeed.
+type Worker struct {
+	Consumer    kafka.Consumer
+	Service     proto.CompanyServiceServer
+	DeadLetters kafka.Producer // Publishes to the dead-letter topic
+	Actor       string
+	// Interceptor wraps every command like a gRPC call, typically the
+	// idempotency interceptor so redelivered commands are applied once.
+	Interceptor  grpc.UnaryServerInterceptor
+	RetryBackoff time.Duration
+}
+
+func NewWorker(consumer kafka.Consumer, service proto.CompanyServiceServer, deadLetters kafka.Producer, actor string) *Worker {
+	return &Worker{
+		Consumer:     consumer,
+		Service:      service,
+		DeadLetters:  deadLetters,
+		Actor:        actor,
+		RetryBackoff: time.Second,
+	}
+}
+
+// Run processes commands until ctx is done. Failures to fetch or commit,
+// such as those during a consumer group rebalance, are retried like failed
+// commands, so the worker only stops with ctx.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		var msg kafka.Message
+		err := w.retry(ctx, "fetch command", func() error {
+			var err error
+			msg, err = w.Consumer.Fetch(ctx)
+			return err
+		})
+		if err == nil {
+			err = w.process(ctx, msg)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// process applies msg, dead-lettering it when it can never be applied, then
+// commits it. A failed commit is retried without applying msg again.
+func (w *Worker) process(ctx context.Context, msg kafka.Message) error {
+	at := fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+	err := w.retry(ctx, "process command at "+at, func() error {
+		err := w.apply(ctx, msg)
+		if err != nil && isPoison(err) {
+			log.Printf("Dead-lettering command at %s: %v", at, err)
+			err = w.deadLetter(ctx, msg, err)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return w.retry(ctx, "commit command at "+at, func() error {
+		return w.Consumer.Commit(ctx, msg)
+	})
+}
+
+// retry calls fn until it succeeds or ctx is done, backing off between
+// failures.
+func (w *Worker) retry(ctx context.Context, what string, fn func() error) error {
+	backoff := w.RetryBackoff
+	for {
+		err := fn()
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		log.Printf("Failed to %s, retrying in %s: %v", what, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// poisonError marks a message that can never be applied.
+type poisonError struct {
+	err error
+}
+
+func (e *poisonError) Error() string {
+	return e.err.Error()
+}
+
+func isPoison(err error) bool {
+	var poison *poisonError
+	if errors.As(err, &poison) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists, codes.FailedPrecondition,
+		codes.OutOfRange, codes.PermissionDenied, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+func (w *Worker) apply(ctx context.Context, msg kafka.Message) error {
+	command, err := Decode(msg)
+	if err != nil {
+		return &poisonError{err: err}
+	}
+
+	ctx = audit.ContextWithActor(ctx, w.Actor)
+	if command.CommandId != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(idempotency.MetadataKey, command.CommandId))
+	}
+
+	var method string
+	var req interface{}
+	var handler grpc.UnaryHandler
+	switch c := command.Command.(type) {
+	case *proto.CompanyCommand_Create:
+		method, req = proto.CompanyService_CreateCompany_FullMethodName, c.Create
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return w.Service.CreateCompany(ctx, req.(*proto.CreateCompanyRequest))
+		}
+	case *proto.CompanyCommand_Update:
+		method, req = proto.CompanyService_UpdateCompany_FullMethodName, c.Update
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return w.Service.UpdateCompany(ctx, req.(*proto.UpdateCompanyRequest))
+		}
+	case *proto.CompanyCommand_Delete:
+		method, req = proto.CompanyService_DeleteCompany_FullMethodName, c.Delete
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return w.Service.DeleteCompany(ctx, req.(*proto.DeleteCompanyRequest))
+		}
+	default:
+		return &poisonError{err: errors.New("command has no create, update or delete")}
+	}
+
+	if w.Interceptor == nil {
+		_, err = handler(ctx, req)
+		return err
+	}
+	_, err = w.Interceptor(ctx, req, &grpc.UnaryServerInfo{Server: w.Service, FullMethod: method}, handler)
+	return err
+}
+
+func (w *Worker) deadLetter(ctx context.Context, msg kafka.Message, cause error) error {
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[HeaderError] = cause.Error()
+	headers[HeaderTopic] = msg.Topic
+	headers[HeaderPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderOffset] = strconv.FormatInt(msg.Offset, 10)
+
+	return w.DeadLetters.Publish(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers})
+}
+
+// Decode parses a command, as binary protobuf when the content-type header
+// says so and as JSON otherwise.
+func Decode(msg kafka.Message) (*proto.CompanyCommand, error) {
+	command := &proto.CompanyCommand{}
+	var err error
+	if msg.Headers[events.HeaderContentType] == events.ContentTypeProtobuf {
+		err = gproto.Unmarshal(msg.Value, command)
+	} else {
+		err = protojson.Unmarshal(msg.Value, command)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %v", err)
+	}
+	return command, nil
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeConsumer struct {
+	messages       []kafka.Message
+	committed      []int64
+	commitFailures int
+	cancel         context.CancelFunc
+}
+
+func (c *fakeConsumer) Fetch(ctx context.Context) (kafka.Message, error) {
+	if len(c.messages) == 0 {
+		c.cancel()
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	}
+	msg := c.messages[0]
+	c.messages = c.messages[1:]
+	return msg, nil
+}
+
+func (c *fakeConsumer) Commit(ctx context.Context, msg kafka.Message) error {
+	if c.commitFailures > 0 {
+		c.commitFailures--
+		return errors.New("rebalance in progress")
+	}
+	c.committed = append(c.committed, msg.Offset)
+	return nil
+}
+
+func (c *fakeConsumer) Close() error {
+	return nil
+}
+
+type stubService struct {
+	proto.UnimplementedCompanyServiceServer
+	created  []*proto.Company
+	actors   []string
+	failures int
+}
+
+func (s *stubService) CreateCompany(ctx context.Context, req *proto.CreateCompanyRequest) (*proto.CreateCompanyResponse, error) {
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("connection reset")
+	}
+	if req.Company.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	s.created = append(s.created, req.Company)
+	s.actors = append(s.actors, audit.ActorFromContext(ctx))
+	return &proto.CreateCompanyResponse{Company: req.Company}, nil
+}
+
+func TestWorkerAppliesCommandsAndDeadLettersPoison(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	consumer := &fakeConsumer{cancel: cancel, commitFailures: 1, messages: []kafka.Message{
+		{Topic: "company_commands", Offset: 1, Key: "a", Value: []byte(`{"command_id": "a", "create": {"company": {"name": "Test Co"}}}`)},
+		{Topic: "company_commands", Offset: 2, Key: "b", Value: []byte(`{"command_id": "b", "create": {"company": {"name": ""}}}`)},
+		{Topic: "company_commands", Offset: 3, Key: "c", Value: []byte(`not json`)},
+	}}
+	service := &stubService{failures: 2}
+	deadLetters := kafka.NewFake()
+
+	worker := NewWorker(consumer, service, deadLetters, audit.SystemActor("kafka-commands"))
+	worker.RetryBackoff = time.Millisecond
+
+	assert.NoError(t, worker.Run(ctx))
+
+	assert.Len(t, service.created, 1)
+	assert.Equal(t, "Test Co", service.created[0].Name)
+	assert.Equal(t, []string{"system:kafka-commands"}, service.actors)
+	assert.Equal(t, []int64{1, 2, 3}, consumer.committed)
+
+	assert.Len(t, deadLetters.Messages(), 2)
+	assert.Equal(t, "b", deadLetters.Messages()[0].Key)
+	assert.Equal(t, "rpc error: code = InvalidArgument desc = name is required", deadLetters.Messages()[0].Headers[HeaderError])
+	assert.Equal(t, "company_commands", deadLetters.Messages()[0].Headers[HeaderTopic])
+	assert.Equal(t, "3", deadLetters.Messages()[1].Headers[HeaderOffset])
+}
+
+func TestDecodeProtobufCommand(t *testing.T) {
+	command, err := Decode(kafka.Message{
+		Headers: map[string]string{"content-type": "application/x-protobuf"},
+		Value:   []byte{0x0a, 0x01, 'x', 0x22, 0x02, 0x08, 0x07},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "x", command.CommandId)
+	assert.Equal(t, int64(7), command.GetDelete().Id)
+}
@@ -0,0 +1,133 @@
+package company
+
+import (
+	"company-service/internal/audit"
+	"company-service/proto"
+	"context"
+	"crypto/ed25519"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *CompanyServiceImpl) ListAuditEntries(ctx context.Context, req *proto.ListAuditEntriesRequest) (*proto.ListAuditEntriesResponse, error) {
+	filter := audit.Filter{
+		CompanyID: req.CompanyId,
+		Actor:     req.Actor,
+		Limit:     int(req.PageSize),
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if req.From != nil {
+		filter.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		filter.To = req.To.AsTime()
+	}
+	if req.PageToken != "" {
+		afterID, err := strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %q", req.PageToken)
+		}
+		filter.AfterID = afterID
+	}
+
+	entries, err := audit.List(ctx, s.DB, filter)
+	if err != nil {
+		log.Printf("Failed to list audit entries: %v", err)
+		return nil, err
+	}
+
+	resp := &proto.ListAuditEntriesResponse{}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, &proto.AuditEntry{
+			Id:        entry.ID,
+			CompanyId: entry.CompanyID,
+			Actor:     entry.Actor,
+			Action:    entry.Action,
+			Before:    entry.Before,
+			After:     entry.After,
+			CreatedAt: timestamppb.New(entry.CreatedAt),
+			Seq:       entry.Seq,
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+		})
+	}
+	if len(entries) == filter.Limit {
+		resp.NextPageToken = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	return resp, nil
+}
+
+func (s *CompanyServiceImpl) VerifyAuditChain(ctx context.Context, req *proto.VerifyAuditChainRequest) (*proto.VerifyAuditChainResponse, error) {
+	result, err := audit.Verify(ctx, s.DB)
+	if err != nil {
+		log.Printf("Failed to verify audit chain: %v", err)
+		return nil, err
+	}
+
+	resp := &proto.VerifyAuditChainResponse{
+		Valid:           result.Valid,
+		EntriesVerified: result.EntriesVerified,
+		FirstBrokenSeq:  result.FirstBrokenSeq,
+		Reason:          result.Reason,
+		HeadSeq:         result.Head.Seq,
+		HeadHash:        result.Head.Hash,
+	}
+	if !result.Valid {
+		log.Printf("Audit chain is broken at entry %d: %s", result.FirstBrokenSeq, result.Reason)
+	}
+
+	if req.Checkpoint != nil && resp.Valid {
+		if s.AuditSigningKey == nil {
+			return nil, status.Error(codes.FailedPrecondition, "audit checkpoints are not configured")
+		}
+		checkpoint := checkpointFromProto(req.Checkpoint)
+		publicKey := s.AuditSigningKey.Public().(ed25519.PublicKey)
+		if err := audit.VerifyCheckpoint(ctx, s.DB, publicKey, checkpoint); err != nil {
+			resp.Valid = false
+			resp.FirstBrokenSeq = checkpoint.Seq
+			resp.Reason = err.Error()
+		}
+	}
+	return resp, nil
+}
+
+func (s *CompanyServiceImpl) ExportAuditCheckpoint(ctx context.Context, req *proto.ExportAuditCheckpointRequest) (*proto.AuditCheckpoint, error) {
+	if s.AuditSigningKey == nil {
+		return nil, status.Error(codes.FailedPrecondition, "audit checkpoints are not configured")
+	}
+
+	head, err := audit.Head(ctx, s.DB)
+	if err != nil {
+		log.Printf("Failed to read audit chain head: %v", err)
+		return nil, err
+	}
+
+	checkpoint := audit.SignCheckpoint(s.AuditSigningKey, head, time.Now())
+	return &proto.AuditCheckpoint{
+		Seq:       checkpoint.Seq,
+		Hash:      checkpoint.Hash,
+		SignedAt:  timestamppb.New(checkpoint.SignedAt),
+		PublicKey: checkpoint.PublicKey,
+		Signature: checkpoint.Signature,
+	}, nil
+}
+
+func checkpointFromProto(checkpoint *proto.AuditCheckpoint) audit.Checkpoint {
+	return audit.Checkpoint{
+		Seq:       checkpoint.Seq,
+		Hash:      checkpoint.Hash,
+		SignedAt:  checkpoint.SignedAt.AsTime(),
+		PublicKey: checkpoint.PublicKey,
+		Signature: checkpoint.Signature,
+	}
+}
@@ -0,0 +1,499 @@
+package company
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/db"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxBatchSize = 1000
+
+// batch tracks per-item results. In ATOMIC mode the first failure fails the
+// whole request; in BEST_EFFORT mode failures are recorded and the rest applied.
+type batch struct {
+	mode    proto.BatchMode
+	results []*proto.BatchItemResult
+}
+
+func newBatch(mode proto.BatchMode, size int) (*batch, error) {
+	if size == 0 {
+		return nil, status.Error(codes.InvalidArgument, "batch is empty")
+	}
+	if size > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "batch must contain at most %d items", maxBatchSize)
+	}
+	b := &batch{mode: mode, results: make([]*proto.BatchItemResult, size)}
+	for i := range b.results {
+		b.results[i] = &proto.BatchItemResult{Index: int32(i)}
+	}
+	return b, nil
+}
+
+// attempt returns a copy of b for one run of the batch transaction, so the
+// results of a run that is retried do not carry over into the next.
+func (b *batch) attempt() *batch {
+	results := make([]*proto.BatchItemResult, len(b.results))
+	for i, r := range b.results {
+		results[i] = &proto.BatchItemResult{Index: r.Index, Code: r.Code, Error: r.Error, Company: r.Company}
+	}
+	return &batch{mode: b.mode, results: results}
+}
+
+func (b *batch) fail(index int, err error) error {
+	st := status.Convert(err)
+	if b.mode == proto.BatchMode_ATOMIC {
+		return status.Errorf(st.Code(), "item %d: %s", index, st.Message())
+	}
+	b.results[index].Code = int32(st.Code())
+	b.results[index].Error = st.Message()
+	return nil
+}
+
+// apply runs fn for all rows as one statement. In BEST_EFFORT mode a failing
+// statement is retried row by row under savepoints so a bad row only fails itself.
+func (b *batch) apply(ctx context.Context, tx db.Querier, rows []int, fn func(rows []int) error) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if b.mode == proto.BatchMode_ATOMIC {
+		return fn(rows)
+	}
+	if err := withSavepoint(ctx, tx, func() error { return fn(rows) }); err == nil {
+		return nil
+	}
+	for _, row := range rows {
+		if err := withSavepoint(ctx, tx, func() error { return fn([]int{row}) }); err != nil {
+			_ = b.fail(row, err)
+		}
+	}
+	return nil
+}
+
+func withSavepoint(ctx context.Context, tx db.Querier, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); rollbackErr != nil {
+			return rollbackErr
+		}
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item")
+	return err
+}
+
+func (b *batch) response() *proto.BatchCompaniesResponse {
+	return &proto.BatchCompaniesResponse{Results: b.results}
+}
+
+func (s *CompanyServiceImpl) BatchCreateCompanies(ctx context.Context, req *proto.BatchCreateCompaniesRequest) (*proto.BatchCompaniesResponse, error) {
+	b, err := newBatch(req.Mode, len(req.Companies))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []int
+	for i, company := range req.Companies {
+		if err := validateCompany(company); err != nil {
+			if err := b.fail(i, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		rows = append(rows, i)
+	}
+
+	var run *batch
+	var applied []int
+	var created map[int]*proto.Company
+	err = s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		run = b.attempt()
+		created = make(map[int]*proto.Company)
+		var err error
+		applied, err = s.uniqueRows(ctx, run, rows, req.Companies, nil)
+		if err != nil {
+			return err
+		}
+
+		err = run.apply(ctx, tx, applied, func(rows []int) error {
+			ids, err := nextCompanyIDs(ctx, tx, len(rows))
+			if err != nil {
+				return err
+			}
+			companies := make([]*proto.Company, len(rows))
+			for j, row := range rows {
+				companies[j] = req.Companies[row]
+			}
+			columns := companyArrays(companies)
+			query := `
+				INSERT INTO companies (id, name, description, employees, registered, type, registration_number)
+				SELECT id, name, description, employees, registered, type, NULLIF(registration_number, '')
+				FROM unnest($1::bigint[], $2::text[], $3::text[], $4::int[], $5::boolean[], $6::text[], $7::text[])
+				    AS v(id, name, description, employees, registered, type, registration_number)
+				RETURNING ` + companyColumns
+			result, err := tx.QueryContext(ctx, query, pq.Array(ids), columns.names, columns.descriptions, columns.employees,
+				columns.registered, columns.types, columns.registrationNumbers)
+			if err != nil {
+				return keyTaken(err)
+			}
+			byID, err := scanCompaniesByID(result)
+			if err != nil {
+				return err
+			}
+			for j, row := range rows {
+				if company, ok := byID[ids[j]]; ok {
+					created[row] = company
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to create companies: %v", err)
+			return err
+		}
+
+		for _, row := range applied {
+			company, ok := created[row]
+			if !ok {
+				continue
+			}
+			err := audit.Record(ctx, audit.Entry{
+				CompanyID: company.Id,
+				Actor:     audit.ActorFromContext(ctx),
+				Action:    audit.ActionCompanyCreate,
+				After:     company,
+			})
+			if err != nil {
+				log.Printf("Failed to audit creation of company with id %d: %v", company.Id, err)
+				return err
+			}
+			run.results[row].Company = company
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, row := range applied {
+		if company, ok := created[row]; ok {
+			s.publishEvent(ctx, "CREATE", company)
+		}
+	}
+	return run.response(), nil
+}
+
+func (s *CompanyServiceImpl) BatchUpdateCompanies(ctx context.Context, req *proto.BatchUpdateCompaniesRequest) (*proto.BatchCompaniesResponse, error) {
+	b, err := newBatch(req.Mode, len(req.Companies))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(req.Companies))
+	for i, company := range req.Companies {
+		if company != nil {
+			ids[i] = company.Id
+		}
+	}
+	valid := func(i int) error { return validateCompanyUpdate(req.Companies[i]) }
+
+	var run *batch
+	var rows []int
+	var before map[int64]*proto.Company
+	var updated map[int]*proto.Company
+	err = s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		run = b.attempt()
+		updated = make(map[int]*proto.Company)
+		var err error
+		rows, before, err = s.lockBatch(ctx, run, ids, valid)
+		if err != nil {
+			return err
+		}
+		rows, err = s.uniqueRows(ctx, run, rows, req.Companies, before)
+		if err != nil {
+			return err
+		}
+
+		err = run.apply(ctx, tx, rows, func(rows []int) error {
+			companies := make([]*proto.Company, len(rows))
+			for j, row := range rows {
+				companies[j] = req.Companies[row]
+			}
+			columns := companyArrays(companies)
+			query := `
+				UPDATE companies AS c
+				SET name = COALESCE(NULLIF(v.name, ''), c.name),
+				    description = COALESCE(NULLIF(v.description, ''), c.description),
+				    employees = COALESCE(NULLIF(v.employees, 0), c.employees),
+				    registered = COALESCE(NULLIF(v.registered, FALSE), c.registered),
+				    type = COALESCE(NULLIF(v.type, ''), c.type),
+				    registration_number = COALESCE(NULLIF(v.registration_number, ''), c.registration_number)
+				FROM unnest($1::bigint[], $2::text[], $3::text[], $4::int[], $5::boolean[], $6::text[], $7::text[])
+				    AS v(id, name, description, employees, registered, type, registration_number)
+				WHERE c.id = v.id
+				RETURNING c.id, c.name, c.description, c.employees, c.registered, c.type, c.deleted_at, c.registration_number,
+				    c.created_at, c.updated_at
+			`
+			result, err := tx.QueryContext(ctx, query, columns.ids, columns.names, columns.descriptions, columns.employees,
+				columns.registered, columns.types, columns.registrationNumbers)
+			if err != nil {
+				return keyTaken(err)
+			}
+			byID, err := scanCompaniesByID(result)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if company, ok := byID[ids[row]]; ok {
+					updated[row] = company
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to update companies: %v", err)
+			return err
+		}
+
+		for _, row := range rows {
+			after, ok := updated[row]
+			if !ok {
+				continue
+			}
+			err := audit.Record(ctx, audit.Entry{
+				CompanyID: after.Id,
+				Actor:     audit.ActorFromContext(ctx),
+				Action:    audit.ActionCompanyUpdate,
+				Before:    before[after.Id],
+				After:     after,
+			})
+			if err != nil {
+				log.Printf("Failed to audit update of company with id %d: %v", after.Id, err)
+				return err
+			}
+			run.results[row].Company = after
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, row := range rows {
+		if after, ok := updated[row]; ok {
+			s.publishUpdate(ctx, before[after.Id], after)
+		}
+	}
+	return run.response(), nil
+}
+
+func (s *CompanyServiceImpl) BatchDeleteCompanies(ctx context.Context, req *proto.BatchDeleteCompaniesRequest) (*proto.BatchCompaniesResponse, error) {
+	b, err := newBatch(req.Mode, len(req.Ids))
+	if err != nil {
+		return nil, err
+	}
+	valid := func(i int) error {
+		if req.Ids[i] <= 0 {
+			return status.Error(codes.InvalidArgument, "id is required")
+		}
+		return nil
+	}
+
+	var run *batch
+	var rows []int
+	var deleted map[int]*proto.Company
+	err = s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		run = b.attempt()
+		deleted = make(map[int]*proto.Company)
+		var before map[int64]*proto.Company
+		var err error
+		rows, before, err = s.lockBatch(ctx, run, req.Ids, valid)
+		if err != nil {
+			return err
+		}
+
+		err = run.apply(ctx, tx, rows, func(rows []int) error {
+			ids := make([]int64, len(rows))
+			for j, row := range rows {
+				ids[j] = req.Ids[row]
+			}
+			query := "UPDATE companies SET deleted_at = NOW() WHERE id = ANY($1::bigint[]) AND deleted_at IS NULL RETURNING " + companyColumns
+			result, err := tx.QueryContext(ctx, query, pq.Array(ids))
+			if err != nil {
+				return err
+			}
+			byID, err := scanCompaniesByID(result)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				if company, ok := byID[req.Ids[row]]; ok {
+					deleted[row] = company
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to delete companies: %v", err)
+			return err
+		}
+
+		for _, row := range rows {
+			if _, ok := deleted[row]; !ok {
+				continue
+			}
+			id := req.Ids[row]
+			err := audit.Record(ctx, audit.Entry{
+				CompanyID: id,
+				Actor:     audit.ActorFromContext(ctx),
+				Action:    audit.ActionCompanyDelete,
+				Before:    before[id],
+			})
+			if err != nil {
+				log.Printf("Failed to audit deletion of company with id %d: %v", id, err)
+				return err
+			}
+			run.results[row].Company = deleted[row]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, row := range rows {
+		if company, ok := deleted[row]; ok {
+			s.publishEvent(ctx, "DELETE", company)
+		}
+	}
+	return run.response(), nil
+}
+
+// lockBatch validates the items, rejects repeated IDs and locks the existing
+// companies. It returns the rows still to apply and their current state.
+func (s *CompanyServiceImpl) lockBatch(ctx context.Context, b *batch, ids []int64, validate func(int) error) ([]int, map[int64]*proto.Company, error) {
+	var rows []int
+	var lockIDs []int64
+	seen := make(map[int64]bool)
+	for i, id := range ids {
+		err := validate(i)
+		if err == nil && seen[id] {
+			err = status.Errorf(codes.InvalidArgument, "company %d appears more than once in the batch", id)
+		}
+		if err != nil {
+			if err := b.fail(i, err); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		seen[id] = true
+		rows = append(rows, i)
+		lockIDs = append(lockIDs, id)
+	}
+
+	query := "SELECT " + companyColumns + " FROM companies WHERE id = ANY($1::bigint[]) AND deleted_at IS NULL ORDER BY id FOR UPDATE"
+	result, err := db.QuerierFromContext(ctx, s.DB).QueryContext(ctx, query, pq.Array(lockIDs))
+	if err != nil {
+		log.Printf("Failed to lock companies: %v", err)
+		return nil, nil, err
+	}
+	current, err := scanCompaniesByID(result)
+	if err != nil {
+		log.Printf("Failed to lock companies: %v", err)
+		return nil, nil, err
+	}
+
+	var found []int
+	for _, row := range rows {
+		if _, ok := current[ids[row]]; !ok {
+			if err := b.fail(row, status.Errorf(codes.NotFound, "company %d not found", ids[row])); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		found = append(found, row)
+	}
+	return found, current, nil
+}
+
+func nextCompanyIDs(ctx context.Context, tx db.Querier, n int) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT nextval(pg_get_serial_sequence('companies', 'id')) FROM generate_series(1, $1)", n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanCompaniesByID(rows *sql.Rows) (map[int64]*proto.Company, error) {
+	defer rows.Close()
+
+	companies := make(map[int64]*proto.Company)
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		companies[company.Id] = company
+	}
+	return companies, keyTaken(rows.Err())
+}
+
+// companyColumnArrays holds companies column by column for unnest().
+type companyColumnArrays struct {
+	ids, names, descriptions, employees, registered, types, registrationNumbers interface{}
+}
+
+func companyArrays(companies []*proto.Company) companyColumnArrays {
+	ids := make([]int64, len(companies))
+	names := make([]string, len(companies))
+	descriptions := make([]string, len(companies))
+	employees := make([]int64, len(companies))
+	registered := make([]bool, len(companies))
+	types := make([]string, len(companies))
+	registrationNumbers := make([]string, len(companies))
+	for i, company := range companies {
+		ids[i] = company.Id
+		names[i] = company.Name
+		descriptions[i] = company.Description
+		employees[i] = int64(company.Employees)
+		registered[i] = company.Registered
+		types[i] = company.Type
+		registrationNumbers[i] = company.RegistrationNumber
+	}
+	return companyColumnArrays{
+		ids:                 pq.Array(ids),
+		names:               pq.Array(names),
+		descriptions:        pq.Array(descriptions),
+		employees:           pq.Array(employees),
+		registered:          pq.Array(registered),
+		types:               pq.Array(types),
+		registrationNumbers: pq.Array(registrationNumbers),
+	}
+}
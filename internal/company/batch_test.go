@@ -0,0 +1,186 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/events"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBatchCreateCompaniesAtomic(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT nextval").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(10).AddRow(11))
+	mock.ExpectQuery("INSERT INTO companies \\(id, name, description, employees, registered, type, registration_number\\)").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(10, "First Co", "", 0, false, "", nil, nil, nil, nil).
+			AddRow(11, "Second Co", "", 0, false, "", nil, nil, nil, nil))
+	expectAuditEntry(mock, int64(10), "anonymous", "COMPANY_CREATE")
+	expectAuditEntry(mock, int64(11), "anonymous", "COMPANY_CREATE")
+	mock.ExpectCommit()
+
+	resp, err := service.BatchCreateCompanies(context.Background(), &proto.BatchCreateCompaniesRequest{
+		Companies: []*proto.Company{{Name: "First Co"}, {Name: "Second Co"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), resp.Results[0].Company.Id)
+	assert.Equal(t, int64(11), resp.Results[1].Company.Id)
+	assert.Len(t, kafkaProducer.Messages(), 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchCreateCompaniesAtomicRejectsInvalidItem(t *testing.T) {
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), nil, nil)
+
+	_, err := service.BatchCreateCompanies(context.Background(), &proto.BatchCreateCompaniesRequest{
+		Companies: []*proto.Company{{Name: "First Co"}, {Name: ""}},
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "item 1: name is required")
+}
+
+func TestBatchCreateCompaniesBestEffortIsolatesFailures(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	mock.ExpectBegin()
+	// The multi-row insert fails, so each row is retried on its own
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(10).AddRow(11))
+	mock.ExpectQuery("INSERT INTO companies").WillReturnError(errors.New("constraint violation"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(12))
+	mock.ExpectQuery("INSERT INTO companies").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(12, "Good Co", "", 0, false, "", nil, nil, nil, nil))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT nextval").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(13))
+	mock.ExpectQuery("INSERT INTO companies").WillReturnError(errors.New("constraint violation"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	expectAuditEntry(mock, int64(12), "anonymous", "COMPANY_CREATE")
+	mock.ExpectCommit()
+
+	resp, err := service.BatchCreateCompanies(context.Background(), &proto.BatchCreateCompaniesRequest{
+		Mode:      proto.BatchMode_BEST_EFFORT,
+		Companies: []*proto.Company{{Name: "Good Co"}, {Name: "Bad Co"}, {Name: ""}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), resp.Results[0].Company.Id)
+	assert.Equal(t, int32(codes.Unknown), resp.Results[1].Code)
+	assert.Equal(t, "constraint violation", resp.Results[1].Error)
+	assert.Equal(t, int32(codes.InvalidArgument), resp.Results[2].Code)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchDeleteCompaniesReportsMissing(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM companies WHERE id = ANY\\(\\$1::bigint\\[\\]\\)").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 5, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE companies SET deleted_at = NOW\\(\\) WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 5, false, "LLC", time.Now(), nil, nil, nil))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_DELETE")
+	mock.ExpectCommit()
+
+	resp, err := service.BatchDeleteCompanies(context.Background(), &proto.BatchDeleteCompaniesRequest{
+		Mode: proto.BatchMode_BEST_EFFORT,
+		Ids:  []int64{1, 1 << 40, 1},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.NotNil(t, resp.Results[0].Company.DeletedAt)
+	assert.Equal(t, int32(codes.NotFound), resp.Results[1].Code)
+	assert.Equal(t, int32(codes.InvalidArgument), resp.Results[2].Code)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	event, err := events.Decode(kafkaProducer.Messages()[0].Value, kafkaProducer.Messages()[0].Headers)
+	assert.NoError(t, err)
+	assert.NotNil(t, event.(*proto.CompanyDeleted).Company.DeletedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "SQLSTATE " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestBatchDeleteCompaniesRetriesDeadlocks(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+	service.Transactor.InitialBackoff, service.Transactor.MaxBackoff = 0, 0
+
+	// The first run does not find company 2 yet and then deadlocks.
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM companies WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 5, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE companies SET deleted_at = NOW\\(\\) WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 5, false, "LLC", time.Now(), nil, nil, nil))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnError(sqlStateError("40P01"))
+	mock.ExpectRollback()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM companies WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Test Co", "", 5, false, "LLC", nil, nil, nil, nil).
+			AddRow(2, "Other Co", "", 5, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE companies SET deleted_at = NOW\\(\\) WHERE id = ANY").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Test Co", "", 5, false, "LLC", time.Now(), nil, nil, nil).
+			AddRow(2, "Other Co", "", 5, false, "LLC", time.Now(), nil, nil, nil))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_DELETE")
+	expectAuditEntry(mock, int64(2), "anonymous", "COMPANY_DELETE")
+	mock.ExpectCommit()
+
+	resp, err := service.BatchDeleteCompanies(context.Background(), &proto.BatchDeleteCompaniesRequest{
+		Mode: proto.BatchMode_BEST_EFFORT,
+		Ids:  []int64{1, 2},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.Equal(t, int32(codes.OK), resp.Results[1].Code)
+	assert.Equal(t, "Other Co", resp.Results[1].Company.Name)
+	assert.Len(t, kafkaProducer.Messages(), 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
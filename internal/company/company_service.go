@@ -1,15 +1,27 @@
 package company
 
 import (
+	"company-service/internal/audit"
 	"company-service/internal/auth"
+	"company-service/internal/changefeed"
+	"company-service/internal/db"
+	"company-service/internal/events"
 	"company-service/internal/kafka"
+	"company-service/internal/webhooks"
 	"company-service/proto"
 	"context"
+	"crypto/ed25519"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type CompanyServiceImpl struct {
@@ -17,105 +29,392 @@ type CompanyServiceImpl struct {
 	AuthService   *auth.AuthService
 	DB            *sql.DB
 	KafkaProducer kafka.Producer // Use the Producer interface for Kafka dependency injection
+
+	AuditSigningKey ed25519.PrivateKey // Signs exported audit checkpoints; checkpoints are disabled when nil
+	Changes         *changefeed.Feed   // Feeds WatchCompanies; watching is disabled when nil
+	EventEncoding   events.Encoding    // Serialisation of published events
+	CloudEvents     events.CloudEvents // Wraps published events as CloudEvents unless its mode is off
+
+	SnapshotProducer kafka.Producer  // Writes to the compacted snapshots topic; RepublishSnapshot is disabled when nil
+	Webhooks         *webhooks.Store // Webhook subscriptions and deliveries; the webhook RPCs are disabled when nil
+	WebhookPolicy    webhooks.Policy // Endpoints webhooks may be registered for
+
+	PublishTimeout time.Duration // Longest a request publishes its events after committing; unlimited when zero
+
+	UniqueKeys UniqueKeys     // Natural keys writes must not duplicate
+	Cluster    *db.Cluster    // Serves reads from replicas; all queries use DB when nil
+	Transactor *db.Transactor // Runs writes in transactions on DB, retrying serialization failures
 }
 
-func NewCompanyServiceImpl(authService *auth.AuthService, db *sql.DB, kafkaProducer kafka.Producer) *CompanyServiceImpl {
+func NewCompanyServiceImpl(authService *auth.AuthService, database *sql.DB, kafkaProducer kafka.Producer) *CompanyServiceImpl {
 	return &CompanyServiceImpl{
 		AuthService:   authService,
-		DB:            db,
+		DB:            database,
 		KafkaProducer: kafkaProducer,
+		EventEncoding: events.EncodingProtobuf,
+		Transactor:    db.NewTransactor(database),
 	}
 }
 
-type CompanyEvent struct {
-	EventType string         `json:"event_type"`
-	Company   *proto.Company `json:"company"`
+// reader returns the pool for reads that may be served by a replica.
+func (s *CompanyServiceImpl) reader(ctx context.Context) *sql.DB {
+	if s.Cluster == nil {
+		return s.DB
+	}
+	return s.Cluster.Reader(ctx)
+}
+
+// publishContext returns the context a request publishes its events with.
+// The request has already committed, so cancelling it does not stop the
+// publishing, but it takes at most PublishTimeout in all; the producer
+// dead-letters the events it could not publish in time.
+func (s *CompanyServiceImpl) publishContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if s.PublishTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.PublishTimeout)
 }
 
 func (s *CompanyServiceImpl) publishEvent(ctx context.Context, eventType string, company *proto.Company) {
-	event := CompanyEvent{EventType: eventType, Company: company}
-	eventData, err := json.Marshal(event)
+	occurredAt := time.Now()
+	event, err := events.New(eventType, company, audit.ActorFromContext(ctx), occurredAt)
 	if err != nil {
-		log.Printf("Failed to marshal event: %v", err)
+		log.Printf("Failed to build event: %v", err)
 		return
 	}
+	s.publish(ctx, eventType, company.Id, event, occurredAt)
+}
 
-	companyIDStr := fmt.Sprintf("%d", company.Id)
-	ctx, cancel := context.WithTimeout(ctx, time.Second*5)
-	defer cancel()
+// publishUpdate publishes an UPDATE event carrying both states of the
+// company and the fields that changed between them.
+func (s *CompanyServiceImpl) publishUpdate(ctx context.Context, before, after *proto.Company) {
+	var changedFields []string
+	for _, change := range diffCompanies(before, after) {
+		changedFields = append(changedFields, change.Field)
+	}
+
+	occurredAt := time.Now()
+	event := events.NewUpdate(before, after, changedFields, audit.ActorFromContext(ctx), occurredAt)
+	s.publish(ctx, "UPDATE", after.Id, event, occurredAt)
+}
+
+func (s *CompanyServiceImpl) publish(ctx context.Context, eventType string, companyID int64, event protoreflect.ProtoMessage, occurredAt time.Time) {
+	companyIDStr := fmt.Sprintf("%d", companyID)
+
+	msg, err := s.eventMessage(companyIDStr, event, occurredAt)
+	if err != nil {
+		log.Printf("Failed to marshal event: %v", err)
+		return
+	}
 
-	if err := s.KafkaProducer.Publish(ctx, companyIDStr, string(eventData)); err != nil {
+	if err := s.KafkaProducer.Publish(ctx, msg); err != nil {
 		log.Printf("Failed to publish %s event for company ID %s: %v", eventType, companyIDStr, err)
 	} else {
 		log.Printf("Successfully published %s event for company ID %s", eventType, companyIDStr)
 	}
 }
 
+// eventMessage encodes event as a Kafka message keyed by the company ID.
+func (s *CompanyServiceImpl) eventMessage(key string, event protoreflect.ProtoMessage, occurredAt time.Time) (kafka.Message, error) {
+	eventData, headers, err := events.Encode(event, s.EventEncoding)
+	if err == nil {
+		eventData, headers, err = s.CloudEvents.Wrap(eventData, headers, key, occurredAt)
+	}
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{Key: key, Value: eventData, Headers: headers}, nil
+}
+
+const (
+	companyColumns = "id, name, description, employees, registered, type, deleted_at, registration_number, created_at, updated_at"
+	historyColumns = "company_id, name, description, employees, registered, type, NULL::timestamptz, registration_number, " +
+		historyCreatedAt + ", valid_from"
+
+	// historyCreatedAt is when the first version of a company was recorded.
+	historyCreatedAt = "(SELECT MIN(first.valid_from) FROM companies_history first WHERE first.company_id = companies_history.company_id)"
+
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// asOfCondition selects the history version that was current at the
+// timestamp bound to the given placeholder.
+func asOfCondition(placeholder int) string {
+	return fmt.Sprintf("valid_from <= $%d AND (valid_to IS NULL OR valid_to > $%d)", placeholder, placeholder)
+}
+
+// companyQuery builds a SELECT over companies, or over companies_history
+// when reading as of a point in time, from the listing filters.
+type companyQuery struct {
+	table         string
+	idColumn      string
+	createdColumn string
+	updatedColumn string
+	columns       string
+	conditions    []string
+	args          []interface{}
+}
+
+func newCompanyQuery(asOf *timestamppb.Timestamp) *companyQuery {
+	q := &companyQuery{table: "companies", idColumn: "id", createdColumn: "created_at", updatedColumn: "updated_at", columns: companyColumns}
+	if asOf != nil {
+		q.table, q.idColumn, q.columns = "companies_history", "company_id", historyColumns
+		q.createdColumn, q.updatedColumn = historyCreatedAt, "valid_from"
+		q.args = append(q.args, asOf.AsTime())
+		q.conditions = append(q.conditions, asOfCondition(len(q.args)))
+	}
+	return q
+}
+
+// where adds a condition whose %d verb is replaced by the placeholder of arg.
+func (q *companyQuery) where(condition string, arg interface{}) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, len(q.args)))
+}
+
+func (q *companyQuery) filter(companyType, nameContains string, includeDeleted bool) {
+	if q.table == "companies" && !includeDeleted {
+		q.conditions = append(q.conditions, "deleted_at IS NULL")
+	}
+	if companyType != "" {
+		q.where("type = $%d", companyType)
+	}
+	if nameContains != "" {
+		q.where("name ILIKE '%%' || $%d || '%%'", nameContains)
+	}
+}
+
+// timeRange keeps the rows whose column lies in [since, before), ignoring
+// the bounds that are not set.
+func (q *companyQuery) timeRange(column string, since, before *timestamppb.Timestamp) {
+	if since != nil {
+		q.where(column+" >= $%d", since.AsTime())
+	}
+	if before != nil {
+		q.where(column+" < $%d", before.AsTime())
+	}
+}
+
+func (q *companyQuery) sql() string {
+	query := fmt.Sprintf("SELECT %s FROM %s", q.columns, q.table)
+	if len(q.conditions) > 0 {
+		query += " WHERE " + strings.Join(q.conditions, " AND ")
+	}
+	return query
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCompany(row rowScanner) (*proto.Company, error) {
+	var company proto.Company
+	var deletedAt sql.NullTime
+	var registrationNumber sql.NullString
+	var createdAt, updatedAt sql.NullTime
+	err := row.Scan(
+		&company.Id,
+		&company.Name,
+		&company.Description,
+		&company.Employees,
+		&company.Registered,
+		&company.Type,
+		&deletedAt,
+		&registrationNumber,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, keyTaken(err)
+	}
+	if deletedAt.Valid {
+		company.DeletedAt = timestamppb.New(deletedAt.Time)
+	}
+	company.RegistrationNumber = registrationNumber.String
+	if createdAt.Valid {
+		company.CreatedAt = timestamppb.New(createdAt.Time)
+	}
+	if updatedAt.Valid {
+		company.UpdatedAt = timestamppb.New(updatedAt.Time)
+	}
+	return &company, nil
+}
+
+// selectCompanyForUpdate locks a live company in the transaction carried by
+// ctx, failing with NotFound when there is none with id.
+func (s *CompanyServiceImpl) selectCompanyForUpdate(ctx context.Context, id int64) (*proto.Company, error) {
+	query := "SELECT " + companyColumns + " FROM companies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	company, err := scanCompany(db.QuerierFromContext(ctx, s.DB).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, status.Errorf(codes.NotFound, "company %d not found", id)
+	}
+	return company, err
+}
+
 func (s *CompanyServiceImpl) CreateCompany(ctx context.Context, req *proto.CreateCompanyRequest) (*proto.CreateCompanyResponse, error) {
+	if err := validateCompany(req.Company); err != nil {
+		return nil, err
+	}
 	company := req.Company
-	query := `
-		INSERT INTO companies (name, description, employees, registered, type)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id
-	`
-	var id int64
-	err := s.DB.QueryRowContext(ctx, query, company.Name, company.Description, company.Employees, company.Registered, company.Type).Scan(&id)
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		if err := s.checkUnique(ctx, 0, s.UniqueKeys.claims(company, nil)); err != nil {
+			log.Printf("Failed to create company: %v", err)
+			return err
+		}
+
+		query := `
+			INSERT INTO companies (name, description, employees, registered, type, registration_number)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING id, created_at, updated_at
+		`
+		var id int64
+		var createdAt, updatedAt time.Time
+		err := tx.QueryRowContext(ctx, query, company.Name, company.Description, company.Employees, company.Registered, company.Type,
+			company.RegistrationNumber).Scan(&id, &createdAt, &updatedAt)
+		if err != nil {
+			log.Printf("Failed to create company: %v", err)
+			return keyTaken(err)
+		}
+		company.Id = id
+		company.CreatedAt = timestamppb.New(createdAt)
+		company.UpdatedAt = timestamppb.New(updatedAt)
+
+		err = audit.Record(ctx, audit.Entry{
+			CompanyID: id,
+			Actor:     audit.ActorFromContext(ctx),
+			Action:    audit.ActionCompanyCreate,
+			After:     company,
+		})
+		if err != nil {
+			log.Printf("Failed to audit creation of company with id %d: %v", id, err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Failed to create company: %v", err)
 		return nil, err
 	}
 
-	company.Id = id
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
 	s.publishEvent(ctx, "CREATE", company)
 
 	return &proto.CreateCompanyResponse{Company: company}, nil
 }
 
 func (s *CompanyServiceImpl) UpdateCompany(ctx context.Context, req *proto.UpdateCompanyRequest) (*proto.UpdateCompanyResponse, error) {
+	if err := validateCompanyUpdate(req.Company); err != nil {
+		return nil, err
+	}
 	company := req.Company
-	query := `
-		UPDATE companies
-		SET name = COALESCE(NULLIF($1, ''), name),
-		    description = COALESCE(NULLIF($2, ''), description),
-		    employees = COALESCE(NULLIF($3::int, 0), employees),
-		    registered = COALESCE(NULLIF($4::boolean, FALSE), registered),
-		    type = COALESCE(NULLIF($5, ''), type)
-		WHERE id = $6
-	`
-	_, err := s.DB.ExecContext(ctx, query, company.Name, company.Description, company.Employees, company.Registered, company.Type, company.Id)
+	var before, after *proto.Company
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		before, err = s.selectCompanyForUpdate(ctx, company.Id)
+		if err != nil {
+			log.Printf("Failed to load company with id %d for update: %v", company.Id, err)
+			return err
+		}
+
+		if err := s.checkUnique(ctx, company.Id, s.UniqueKeys.claims(company, before)); err != nil {
+			log.Printf("Failed to update company with id %d: %v", company.Id, err)
+			return err
+		}
+
+		query := `
+			UPDATE companies
+			SET name = COALESCE(NULLIF($1, ''), name),
+			    description = COALESCE(NULLIF($2, ''), description),
+			    employees = COALESCE(NULLIF($3::int, 0), employees),
+			    registered = COALESCE(NULLIF($4::boolean, FALSE), registered),
+			    type = COALESCE(NULLIF($5, ''), type),
+			    registration_number = COALESCE(NULLIF($6, ''), registration_number)
+			WHERE id = $7 AND deleted_at IS NULL
+			RETURNING ` + companyColumns
+		after, err = scanCompany(db.QuerierFromContext(ctx, s.DB).QueryRowContext(ctx, query, company.Name, company.Description,
+			company.Employees, company.Registered, company.Type, company.RegistrationNumber, company.Id))
+		if err != nil {
+			log.Printf("Failed to update company with id %d: %v", company.Id, err)
+			return err
+		}
+
+		err = audit.Record(ctx, audit.Entry{
+			CompanyID: company.Id,
+			Actor:     audit.ActorFromContext(ctx),
+			Action:    audit.ActionCompanyUpdate,
+			Before:    before,
+			After:     after,
+		})
+		if err != nil {
+			log.Printf("Failed to audit update of company with id %d: %v", company.Id, err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Failed to update company with id %d: %v", company.Id, err)
 		return nil, err
 	}
 
-	s.publishEvent(ctx, "UPDATE", company)
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	s.publishUpdate(ctx, before, after)
 
-	return &proto.UpdateCompanyResponse{Company: company}, nil
+	return &proto.UpdateCompanyResponse{Company: after}, nil
 }
 
 func (s *CompanyServiceImpl) DeleteCompany(ctx context.Context, req *proto.DeleteCompanyRequest) (*proto.CompanyID, error) {
-	query := "DELETE FROM companies WHERE id = $1"
-	_, err := s.DB.ExecContext(ctx, query, req.Id)
+	var deleted *proto.Company
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		before, err := s.selectCompanyForUpdate(ctx, req.Id)
+		if err != nil {
+			log.Printf("Failed to load company with id %d for delete: %v", req.Id, err)
+			return err
+		}
+
+		query := "UPDATE companies SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING " + companyColumns
+		deleted, err = scanCompany(db.QuerierFromContext(ctx, s.DB).QueryRowContext(ctx, query, req.Id))
+		if err != nil {
+			log.Printf("Failed to delete company with id %d: %v", req.Id, err)
+			return err
+		}
+
+		err = audit.Record(ctx, audit.Entry{
+			CompanyID: req.Id,
+			Actor:     audit.ActorFromContext(ctx),
+			Action:    audit.ActionCompanyDelete,
+			Before:    before,
+		})
+		if err != nil {
+			log.Printf("Failed to audit deletion of company with id %d: %v", req.Id, err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Failed to delete company with id %d: %v", req.Id, err)
 		return nil, err
 	}
 
-	s.publishEvent(ctx, "DELETE", &proto.Company{Id: req.Id})
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	s.publishEvent(ctx, "DELETE", deleted)
 
 	return &proto.CompanyID{Id: req.Id}, nil
 }
 
-func (s *CompanyServiceImpl) GetCompany(ctx context.Context, req *proto.CompanyID) (*proto.GetCompanyResponse, error) {
-	var company proto.Company
-	query := "SELECT id, name, description, employees, registered, type FROM companies WHERE id = $1"
-	err := s.DB.QueryRowContext(ctx, query, req.Id).Scan(
-		&company.Id,
-		&company.Name,
-		&company.Description,
-		&company.Employees,
-		&company.Registered,
-		&company.Type,
-	)
+func (s *CompanyServiceImpl) GetCompany(ctx context.Context, req *proto.GetCompanyRequest) (*proto.GetCompanyResponse, error) {
+	query := "SELECT " + companyColumns + " FROM companies WHERE id = $1"
+	if !req.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	args := []interface{}{req.Id}
+	if req.AsOf != nil {
+		query = "SELECT " + historyColumns + " FROM companies_history WHERE company_id = $1 AND " + asOfCondition(2)
+		args = append(args, req.AsOf.AsTime())
+	}
+
+	company, err := scanCompany(s.reader(ctx).QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("Company with id %d not found", req.Id)
@@ -125,15 +424,154 @@ func (s *CompanyServiceImpl) GetCompany(ctx context.Context, req *proto.CompanyI
 		return nil, err
 	}
 
-	return &proto.GetCompanyResponse{Company: &company}, nil
+	return &proto.GetCompanyResponse{Company: company}, nil
+}
+
+func (s *CompanyServiceImpl) ListCompanies(ctx context.Context, req *proto.ListCompaniesRequest) (*proto.ListCompaniesResponse, error) {
+	limit := int(req.PageSize)
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	q := newCompanyQuery(req.AsOf)
+	order := listOrder{by: req.OrderBy, descending: req.Descending}
+	if err := order.seek(q, req.PageToken); err != nil {
+		return nil, err
+	}
+	q.filter(req.Type, req.NameContains, req.IncludeDeleted)
+	q.timeRange(q.createdColumn, req.CreatedSince, req.CreatedBefore)
+	q.timeRange(q.updatedColumn, req.UpdatedSince, req.UpdatedBefore)
+	q.args = append(q.args, limit)
+
+	query := fmt.Sprintf("%s ORDER BY %s LIMIT $%d", q.sql(), order.sql(q), len(q.args))
+	rows, err := s.reader(ctx).QueryContext(ctx, query, q.args...)
+	if err != nil {
+		log.Printf("Failed to list companies: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &proto.ListCompaniesResponse{}
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			log.Printf("Failed to scan company: %v", err)
+			return nil, err
+		}
+		resp.Companies = append(resp.Companies, company)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to list companies: %v", err)
+		return nil, err
+	}
+
+	if len(resp.Companies) == limit {
+		resp.NextPageToken = order.token(resp.Companies[len(resp.Companies)-1])
+	}
+	return resp, nil
+}
+
+// listOrder pages through a listing with keyset pagination. Ordered by id the
+// page token is the last id; ordered by a timestamp it is the last
+// "<unix microseconds>:<id>", id breaking ties between equal timestamps.
+type listOrder struct {
+	by         proto.CompanyOrder
+	descending bool
+}
+
+func (o listOrder) column(q *companyQuery) string {
+	switch o.by {
+	case proto.CompanyOrder_ORDER_CREATED_AT:
+		return q.createdColumn
+	case proto.CompanyOrder_ORDER_UPDATED_AT:
+		return q.updatedColumn
+	}
+	return ""
+}
+
+// seek restricts q to the rows after the page token.
+func (o listOrder) seek(q *companyQuery, token string) error {
+	op := ">"
+	if o.descending {
+		op = "<"
+	}
+	column := o.column(q)
+	if column == "" {
+		var afterID int64
+		if token != "" {
+			var err error
+			if afterID, err = strconv.ParseInt(token, 10, 64); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid page token %q", token)
+			}
+		}
+		if token != "" || !o.descending {
+			q.where(q.idColumn+" "+op+" $%d", afterID)
+		}
+		return nil
+	}
+	if token == "" {
+		return nil
+	}
+
+	micros, id, ok := strings.Cut(token, ":")
+	afterMicros, microsErr := strconv.ParseInt(micros, 10, 64)
+	afterID, idErr := strconv.ParseInt(id, 10, 64)
+	if !ok || microsErr != nil || idErr != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid page token %q", token)
+	}
+	q.args = append(q.args, time.UnixMicro(afterMicros).UTC(), afterID)
+	q.conditions = append(q.conditions, fmt.Sprintf("(%s, %s) %s ($%d, $%d)", column, q.idColumn, op, len(q.args)-1, len(q.args)))
+	return nil
+}
+
+func (o listOrder) sql(q *companyQuery) string {
+	direction := ""
+	if o.descending {
+		direction = " DESC"
+	}
+	if column := o.column(q); column != "" {
+		return column + direction + ", " + q.idColumn + direction
+	}
+	return q.idColumn + direction
+}
+
+func (o listOrder) token(last *proto.Company) string {
+	var at *timestamppb.Timestamp
+	switch o.by {
+	case proto.CompanyOrder_ORDER_CREATED_AT:
+		at = last.CreatedAt
+	case proto.CompanyOrder_ORDER_UPDATED_AT:
+		at = last.UpdatedAt
+	default:
+		return strconv.FormatInt(last.Id, 10)
+	}
+	return fmt.Sprintf("%d:%d", at.AsTime().UnixMicro(), last.Id)
 }
 
 func (s *CompanyServiceImpl) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
 	token, err := s.AuthService.GenerateToken(req.UserId)
 	if err != nil {
 		log.Printf("Failed to generate token: %v", err)
+		_ = s.auditLogin(ctx, req.UserId, audit.ActionLoginFailure)
+		return nil, err
+	}
+
+	if err := s.auditLogin(ctx, req.UserId, audit.ActionLoginSuccess); err != nil {
 		return nil, err
 	}
 
 	return &proto.LoginResponse{Token: token}, nil
 }
+
+func (s *CompanyServiceImpl) auditLogin(ctx context.Context, userID int64, action string) error {
+	return s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		if err := audit.Record(ctx, audit.Entry{Actor: audit.UserActor(userID), Action: action}); err != nil {
+			log.Printf("Failed to audit %s for user %d: %v", action, userID, err)
+			return err
+		}
+		return nil
+	})
+}
@@ -30,7 +30,10 @@ func setupIntegrationTest(t *testing.T) (*CompanyServiceImpl, func()) {
 	}
 
 	// Initialize Kafka producer and AuthService
-	kafkaProducer := kafka.NewKafkaProducer(cfg.KafkaBroker, cfg.KafkaTopicCompanyEvents)
+	kafkaProducer, err := kafka.NewKafkaProducer(kafka.Config{Brokers: cfg.KafkaBrokers}, cfg.KafkaTopicCompanyEvents)
+	if err != nil {
+		t.Fatalf("Could not create Kafka producer: %v", err)
+	}
 	authService := auth.NewAuthService(cfg.JWTSecret)
 
 	// Create service
@@ -1,26 +1,49 @@
 package company
 
 import (
+	"company-service/internal/audit"
 	"company-service/internal/auth"
+	"company-service/internal/events"
 	"company-service/internal/kafka"
 	"company-service/proto"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"testing"
+	"time"
 )
 
+var companyRowColumns = []string{"id", "name", "description", "employees", "registered", "type", "deleted_at", "registration_number", "created_at", "updated_at"}
+
+func expectAuditEntry(mock sqlmock.Sqlmock, companyID interface{}, actor, action string) {
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT seq, hash FROM audit_log").
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}).AddRow(41, "prev-hash"))
+	mock.ExpectExec("INSERT INTO audit_log").
+		WithArgs(int64(42), companyID, actor, action, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "prev-hash", sqlmock.AnyArg(), audit.HashVersion).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
 func TestCreateCompany(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	kafkaProducer := &kafka.KafkaProducerMock{}
+	kafkaProducer := kafka.NewFake()
 	authService := auth.NewAuthService("test-secret")
 
-	// Expecting an INSERT statement
+	// Expecting an INSERT statement audited in the same transaction
+	mock.ExpectBegin()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	mock.ExpectQuery("INSERT INTO companies").
-		WithArgs("Test Co", "A sample company", 50, true, "Corporation").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		WithArgs("Test Co", "A sample company", 50, true, "Corporation", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, createdAt, createdAt))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_CREATE")
+	mock.ExpectCommit()
 
 	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
 
@@ -40,21 +63,63 @@ func TestCreateCompany(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp.Company)
 	assert.Equal(t, int64(1), resp.Company.Id)
-	assert.Len(t, kafkaProducer.PublishedMessages, 1)
-	assert.Contains(t, kafkaProducer.PublishedMessages[0], `"event_type":"CREATE"`)
+	assert.Equal(t, createdAt, resp.Company.CreatedAt.AsTime())
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.Equal(t, "company.CompanyCreated", kafkaProducer.Messages()[0].Headers["event-type"])
+
+	event, err := events.Decode(kafkaProducer.Messages()[0].Value, kafkaProducer.Messages()[0].Headers)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Co", event.(*proto.CompanyCreated).Company.Name)
+	assert.Equal(t, "anonymous", event.(*proto.CompanyCreated).Actor)
+}
+
+func TestPublishingIsCappedByPublishTimeout(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	kafkaProducer.Block()
+	defer kafkaProducer.Unblock()
+
+	mock.ExpectBegin()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO companies").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, createdAt, createdAt))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_CREATE")
+	mock.ExpectCommit()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+	service.PublishTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	resp, err := service.CreateCompany(context.Background(), &proto.CreateCompanyRequest{
+		Company: &proto.Company{Name: "Test Co", Employees: 50, Type: "Corporation"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Company.Id)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, kafkaProducer.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestUpdateCompany(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	kafkaProducer := &kafka.KafkaProducerMock{}
+	kafkaProducer := kafka.NewFake()
 	authService := auth.NewAuthService("test-secret")
 
-	// Expecting an UPDATE statement
-	mock.ExpectExec("UPDATE companies").
-		WithArgs("Updated Co", "Updated description", 100, false, "LLC", int64(1)).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	// Expecting the prior row to be locked, updated and audited in one transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, name, description, employees, registered, type, deleted_at, registration_number, created_at, updated_at FROM companies WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", nil, nil, nil, nil))
+	mock.ExpectQuery("UPDATE companies").
+		WithArgs("Updated Co", "Updated description", 100, false, "LLC", "", int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Updated Co", "Updated description", 100, true, "LLC", nil, nil, nil, nil))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_UPDATE")
+	mock.ExpectCommit()
 
 	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
 
@@ -75,21 +140,36 @@ func TestUpdateCompany(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, resp.Company)
 	assert.Equal(t, int64(1), resp.Company.Id)
-	assert.Len(t, kafkaProducer.PublishedMessages, 1)
-	assert.Contains(t, kafkaProducer.PublishedMessages[0], `"event_type":"UPDATE"`)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.Equal(t, "company.CompanyUpdated", kafkaProducer.Messages()[0].Headers["event-type"])
+
+	event, err := events.Decode(kafkaProducer.Messages()[0].Value, kafkaProducer.Messages()[0].Headers)
+	assert.NoError(t, err)
+	updated := event.(*proto.CompanyUpdated)
+	assert.Equal(t, "Test Co", updated.Before.Name)
+	assert.Equal(t, "Updated Co", updated.Company.Name)
+	assert.Equal(t, []string{"name", "description", "employees", "type"}, updated.ChangedFields)
 }
 
 func TestDeleteCompany(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	kafkaProducer := &kafka.KafkaProducerMock{}
+	kafkaProducer := kafka.NewFake()
 	authService := auth.NewAuthService("test-secret")
 
-	// Expecting a DELETE statement
-	mock.ExpectExec("DELETE FROM companies WHERE id = \\$1").
+	// Expecting a soft delete audited with the final state
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, name, description, employees, registered, type, deleted_at, registration_number, created_at, updated_at FROM companies WHERE id = \\$1 AND deleted_at IS NULL FOR UPDATE").
 		WithArgs(int64(1)).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", nil, nil, nil, nil))
+	mock.ExpectQuery("UPDATE companies SET deleted_at = NOW\\(\\) WHERE id = \\$1 AND deleted_at IS NULL").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", time.Now(), nil, nil, nil))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_DELETE")
+	mock.ExpectCommit()
 
 	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
 
@@ -100,26 +180,146 @@ func TestDeleteCompany(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), resp.Id)
-	assert.Len(t, kafkaProducer.PublishedMessages, 1)
-	assert.Contains(t, kafkaProducer.PublishedMessages[0], `"event_type":"DELETE"`)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.Equal(t, "company.CompanyDeleted", kafkaProducer.Messages()[0].Headers["event-type"])
+
+	event, err := events.Decode(kafkaProducer.Messages()[0].Value, kafkaProducer.Messages()[0].Headers)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Co", event.(*proto.CompanyDeleted).Company.Name)
+	assert.NotNil(t, event.(*proto.CompanyDeleted).Company.DeletedAt)
+}
+
+func TestMutationsOnMissingCompanyReturnNotFound(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafka.NewFake())
+	for i := 0; i < 3; i++ {
+		mock.ExpectBegin()
+		mock.ExpectQuery("FROM companies WHERE id = \\$1 AND deleted_at IS (NOT )?NULL FOR UPDATE").
+			WithArgs(int64(42)).
+			WillReturnRows(sqlmock.NewRows(companyRowColumns))
+		mock.ExpectRollback()
+	}
+
+	_, err := service.UpdateCompany(context.Background(), &proto.UpdateCompanyRequest{Company: &proto.Company{Id: 42, Name: "Nobody Ltd"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = service.DeleteCompany(context.Background(), &proto.DeleteCompanyRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = service.RestoreCompany(context.Background(), &proto.RestoreCompanyRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreCompany(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	authService := auth.NewAuthService("test-secret")
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM companies WHERE id = \\$1 AND deleted_at IS NOT NULL FOR UPDATE").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", deletedAt, nil, nil, nil))
+	mock.ExpectQuery("UPDATE companies SET deleted_at = NULL WHERE id = \\$1").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", nil, nil, nil, nil))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_RESTORE")
+	mock.ExpectCommit()
+
+	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
+
+	resp, err := service.RestoreCompany(context.Background(), &proto.RestoreCompanyRequest{Id: 1})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, resp.Company.DeletedAt)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.Equal(t, "company.CompanyRestored", kafkaProducer.Messages()[0].Headers["event-type"])
+}
+
+func TestPurgeDeletedCompanies(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	authService := auth.NewAuthService("test-secret")
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM companies").
+		WithArgs(sqlmock.AnyArg(), purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(3, "Gone Co", "", 1, false, "LLC", deletedAt, nil, nil, nil))
+	expectAuditEntry(mock, int64(3), "system:purge", "COMPANY_PURGE")
+	mock.ExpectCommit()
+
+	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
+
+	purged, err := service.PurgeDeletedCompanies(context.Background(), 24*time.Hour)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Len(t, kafkaProducer.Messages(), 1)
+	assert.Equal(t, "company.CompanyPurged", kafkaProducer.Messages()[0].Headers["event-type"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeTombstonesPurgedCompanies(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM companies").
+		WithArgs(sqlmock.AnyArg(), purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(3, "Gone Co", "", 1, false, "LLC", deletedAt, nil, nil, nil))
+	expectAuditEntry(mock, int64(3), "system:purge", "COMPANY_PURGE")
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM companies").
+		WithArgs(sqlmock.AnyArg(), purgeBatchSize).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(4, "Also Gone Co", "", 1, false, "LLC", deletedAt, nil, nil, nil))
+	mock.ExpectRollback()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafka.NewFake())
+	snapshots := kafka.NewFake()
+	service.SnapshotProducer = snapshots
+
+	purged, err := service.PurgeDeletedCompanies(context.Background(), 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	if assert.Len(t, snapshots.Messages(), 1) {
+		assert.Equal(t, "3", snapshots.Messages()[0].Key)
+		assert.Nil(t, snapshots.Messages()[0].Value)
+	}
+
+	// A tombstone that cannot be published keeps the company for the next run.
+	snapshots.FailNext(1, errors.New("broker unavailable"))
+	purged, err = service.PurgeDeletedCompanies(context.Background(), 24*time.Hour)
+	assert.Error(t, err)
+	assert.Zero(t, purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetCompany(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	kafkaProducer := &kafka.KafkaProducerMock{}
+	kafkaProducer := kafka.NewFake()
 	authService := auth.NewAuthService("test-secret")
 
 	// Expecting a SELECT statement
-	mock.ExpectQuery("SELECT id, name, description, employees, registered, type FROM companies WHERE id = \\$1").
+	mock.ExpectQuery("SELECT id, name, description, employees, registered, type, deleted_at, registration_number, created_at, updated_at FROM companies WHERE id = \\$1 AND deleted_at IS NULL").
 		WithArgs(int64(1)).
-		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "employees", "registered", "type"}).
-			AddRow(1, "Test Co", "A sample company", 50, true, "Corporation"))
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Test Co", "A sample company", 50, true, "Corporation", nil, nil, nil, nil))
 
 	service := NewCompanyServiceImpl(authService, db, kafkaProducer)
 
-	req := &proto.CompanyID{Id: 1}
+	req := &proto.GetCompanyRequest{Id: 1}
 
 	resp, err := service.GetCompany(context.Background(), req)
 
@@ -134,9 +334,124 @@ func TestGetCompany(t *testing.T) {
 	assert.Equal(t, "Corporation", resp.Company.Type)
 }
 
+func TestGetCompanyAsOf(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	authService := auth.NewAuthService("test-secret")
+	asOf := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Expecting the version valid at as_of to be read from the history table
+	mock.ExpectQuery("SELECT company_id, name, description, employees, registered, type, NULL::timestamptz, registration_number, .* FROM companies_history WHERE company_id = \\$1 AND valid_from <= \\$2").
+		WithArgs(int64(1), asOf).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "Old Co", "A sample company", 10, false, "LLC", nil, nil, nil, nil))
+
+	service := NewCompanyServiceImpl(authService, db, nil)
+
+	resp, err := service.GetCompany(context.Background(), &proto.GetCompanyRequest{Id: 1, AsOf: timestamppb.New(asOf)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Old Co", resp.Company.Name)
+	assert.Equal(t, int32(10), resp.Company.Employees)
+}
+
+func TestListCompanies(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	authService := auth.NewAuthService("test-secret")
+
+	mock.ExpectQuery("SELECT id, name, description, employees, registered, type, deleted_at, registration_number, created_at, updated_at FROM companies WHERE id > \\$1 AND deleted_at IS NULL AND type = \\$2 ORDER BY id LIMIT \\$3").
+		WithArgs(int64(5), "LLC", 2).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(6, "First Co", "", 10, false, "LLC", nil, nil, nil, nil).
+			AddRow(9, "Second Co", "", 20, true, "LLC", nil, nil, nil, nil))
+
+	service := NewCompanyServiceImpl(authService, db, nil)
+
+	resp, err := service.ListCompanies(context.Background(), &proto.ListCompaniesRequest{PageSize: 2, PageToken: "5", Type: "LLC"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Companies, 2)
+	assert.Equal(t, "Second Co", resp.Companies[1].Name)
+	assert.Equal(t, "9", resp.NextPageToken)
+}
+
+func TestListCompaniesByUpdatedAt(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := since.Add(90 * time.Minute)
+
+	mock.ExpectQuery("FROM companies WHERE \\(updated_at, id\\) > \\(\\$1, \\$2\\) AND deleted_at IS NULL AND updated_at >= \\$3 ORDER BY updated_at, id LIMIT \\$4").
+		WithArgs(since.Add(time.Hour), int64(4), since, 2).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(2, "First Co", "", 10, false, "LLC", nil, nil, since, since.Add(time.Hour)).
+			AddRow(1, "Second Co", "", 20, true, "LLC", nil, nil, since, last))
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+
+	resp, err := service.ListCompanies(context.Background(), &proto.ListCompaniesRequest{
+		PageSize:     2,
+		PageToken:    fmt.Sprintf("%d:4", since.Add(time.Hour).UnixMicro()),
+		UpdatedSince: timestamppb.New(since),
+		OrderBy:      proto.CompanyOrder_ORDER_UPDATED_AT,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Companies, 2)
+	assert.Equal(t, last, resp.Companies[1].UpdatedAt.AsTime())
+	assert.Equal(t, fmt.Sprintf("%d:1", last.UnixMicro()), resp.NextPageToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = service.ListCompanies(context.Background(), &proto.ListCompaniesRequest{
+		PageToken: "5",
+		OrderBy:   proto.CompanyOrder_ORDER_CREATED_AT,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetCompanyHistory(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	authService := auth.NewAuthService("test-secret")
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(24 * time.Hour)
+
+	mock.ExpectQuery("FROM companies_history").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "company_id", "name", "description", "employees", "registered", "type", "valid_from", "valid_to", "registration_number"}).
+			AddRow(1, 1, "Test Co", "A sample company", 50, true, "Corporation", created, updated, nil).
+			AddRow(2, 1, "Test Co", "A sample company", 75, true, "Corporation", updated, nil, nil))
+
+	service := NewCompanyServiceImpl(authService, db, nil)
+
+	resp, err := service.GetCompanyHistory(context.Background(), &proto.GetCompanyHistoryRequest{Id: 1})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Versions, 2)
+	assert.Equal(t, updated, resp.Versions[0].ValidTo.AsTime())
+	assert.Nil(t, resp.Versions[1].ValidTo)
+	assert.Equal(t, []*proto.FieldChange{{Field: "employees", OldValue: "50", NewValue: "75"}}, resp.Versions[1].Changes)
+}
+
 func TestLogin(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
 	authService := auth.NewAuthService("test-secret")
-	service := NewCompanyServiceImpl(authService, nil, nil)
+	service := NewCompanyServiceImpl(authService, db, nil)
+
+	// Expecting the successful login to be audited
+	mock.ExpectBegin()
+	expectAuditEntry(mock, nil, "user:1", "LOGIN_SUCCESS")
+	mock.ExpectCommit()
 
 	req := &proto.LoginRequest{UserId: 1}
 
@@ -145,4 +460,34 @@ func TestLogin(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resp.Token)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAuditEntries(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	authService := auth.NewAuthService("test-secret")
+	service := NewCompanyServiceImpl(authService, db, nil)
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, COALESCE\\(seq, 0\\), COALESCE\\(company_id, 0\\), actor, action, before, after, created_at").
+		WithArgs(int64(10), int64(1), "user:7", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seq", "company_id", "actor", "action", "before", "after", "created_at", "prev_hash", "hash", "hash_version"}).
+			AddRow(11, 11, 1, "user:7", "COMPANY_UPDATE", []byte(`{"id":1,"name":"Old Co"}`), []byte(`{"id":1,"name":"New Co"}`), createdAt, "h10", "h11", 1).
+			AddRow(12, 12, 1, "user:7", "COMPANY_DELETE", []byte(`{"id":1,"name":"New Co"}`), nil, createdAt, "h11", "h12", 2))
+
+	req := &proto.ListAuditEntriesRequest{CompanyId: 1, Actor: "user:7", PageSize: 2, PageToken: "10"}
+
+	resp, err := service.ListAuditEntries(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, resp.Entries, 2)
+	assert.Equal(t, "Old Co", resp.Entries[0].Before.Name)
+	assert.Equal(t, "New Co", resp.Entries[0].After.Name)
+	assert.Nil(t, resp.Entries[1].After)
+	assert.Equal(t, createdAt, resp.Entries[0].CreatedAt.AsTime())
+	assert.Equal(t, "h11", resp.Entries[1].PrevHash)
+	assert.Equal(t, "12", resp.NextPageToken)
 }
@@ -0,0 +1,182 @@
+package company
+
+import (
+	"bytes"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultExportChunkSize = 1000
+	maxExportChunkSize     = 10000
+)
+
+var exportCSVHeader = []string{"id", "name", "description", "employees", "registered", "type", "deleted_at", "registration_number", "created_at", "updated_at"}
+
+// ExportCompanies streams every company matching the filters. Rows are read
+// through a server-side cursor one chunk at a time, inside a read-only
+// snapshot so the export is consistent however long it takes.
+func (s *CompanyServiceImpl) ExportCompanies(req *proto.ExportCompaniesRequest, stream proto.CompanyService_ExportCompaniesServer) error {
+	ctx := stream.Context()
+
+	chunkSize := int(req.ChunkSize)
+	if chunkSize <= 0 {
+		chunkSize = defaultExportChunkSize
+	}
+	if chunkSize > maxExportChunkSize {
+		chunkSize = maxExportChunkSize
+	}
+	if _, ok := proto.ExportFormat_name[int32(req.Format)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported export format %s", req.Format)
+	}
+
+	q := newCompanyQuery(req.AsOf)
+	q.filter(req.Type, req.NameContains, req.IncludeDeleted)
+	q.timeRange(q.createdColumn, req.CreatedSince, req.CreatedBefore)
+	q.timeRange(q.updatedColumn, req.UpdatedSince, req.UpdatedBefore)
+
+	tx, err := s.reader(ctx).BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		log.Printf("Failed to begin transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	declare := fmt.Sprintf("DECLARE company_export NO SCROLL CURSOR FOR %s ORDER BY %s", q.sql(), q.idColumn)
+	if _, err := tx.ExecContext(ctx, declare, q.args...); err != nil {
+		log.Printf("Failed to open export cursor: %v", err)
+		return err
+	}
+
+	exported := 0
+	for first := true; ; first = false {
+		companies, err := fetchCompanies(ctx, tx, chunkSize)
+		if err != nil {
+			log.Printf("Failed to export companies: %v", err)
+			return err
+		}
+		// An empty CSV export still gets its header.
+		if len(companies) == 0 && !(first && req.Format == proto.ExportFormat_EXPORT_CSV) {
+			break
+		}
+
+		chunk, err := encodeExportChunk(req.Format, companies, first)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		exported += len(companies)
+
+		if len(companies) < chunkSize {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("Exported %d companies", exported)
+	return nil
+}
+
+func fetchCompanies(ctx context.Context, tx *sql.Tx, count int) ([]*proto.Company, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("FETCH FORWARD %d FROM company_export", count))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	companies := make([]*proto.Company, 0, count)
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		companies = append(companies, company)
+	}
+	return companies, rows.Err()
+}
+
+func encodeExportChunk(format proto.ExportFormat, companies []*proto.Company, first bool) (*proto.ExportCompaniesChunk, error) {
+	chunk := &proto.ExportCompaniesChunk{Rows: int32(len(companies))}
+
+	switch format {
+	case proto.ExportFormat_EXPORT_CSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if first {
+			w.Write(exportCSVHeader) //nolint:errcheck
+		}
+		for _, c := range companies {
+			w.Write([]string{ //nolint:errcheck
+				strconv.FormatInt(c.Id, 10), c.Name, c.Description, strconv.FormatInt(int64(c.Employees), 10),
+				strconv.FormatBool(c.Registered), c.Type, csvTimestamp(c.DeletedAt), c.RegistrationNumber,
+				csvTimestamp(c.CreatedAt), csvTimestamp(c.UpdatedAt),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		chunk.Payload = &proto.ExportCompaniesChunk_Data{Data: buf.Bytes()}
+
+	case proto.ExportFormat_EXPORT_JSONL:
+		var buf bytes.Buffer
+		marshaler := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+		for _, c := range companies {
+			line, err := marshaler.Marshal(c)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+		chunk.Payload = &proto.ExportCompaniesChunk_Data{Data: buf.Bytes()}
+
+	case proto.ExportFormat_EXPORT_COLUMNAR:
+		columns := &proto.CompanyColumns{}
+		for _, c := range companies {
+			columns.Id = append(columns.Id, c.Id)
+			columns.Name = append(columns.Name, c.Name)
+			columns.Description = append(columns.Description, c.Description)
+			columns.Employees = append(columns.Employees, c.Employees)
+			columns.Registered = append(columns.Registered, c.Registered)
+			columns.Type = append(columns.Type, c.Type)
+			columns.DeletedAt = append(columns.DeletedAt, unixMicros(c.DeletedAt))
+			columns.RegistrationNumber = append(columns.RegistrationNumber, c.RegistrationNumber)
+			columns.CreatedAt = append(columns.CreatedAt, unixMicros(c.CreatedAt))
+			columns.UpdatedAt = append(columns.UpdatedAt, unixMicros(c.UpdatedAt))
+		}
+		chunk.Payload = &proto.ExportCompaniesChunk_Columns{Columns: columns}
+	}
+	return chunk, nil
+}
+
+// csvTimestamp formats t for CSV, leaving timestamps that are not set empty.
+func csvTimestamp(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().Format(time.RFC3339Nano)
+}
+
+// unixMicros returns t in Unix microseconds, or 0 when it is not set.
+func unixMicros(t *timestamppb.Timestamp) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.AsTime().UnixMicro()
+}
@@ -0,0 +1,95 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/proto"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type exportStream struct {
+	grpc.ServerStream
+	chunks []*proto.ExportCompaniesChunk
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) Send(chunk *proto.ExportCompaniesChunk) error {
+	s.chunks = append(s.chunks, chunk)
+	return nil
+}
+
+func TestExportCompaniesCSV(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DECLARE company_export NO SCROLL CURSOR FOR SELECT .* FROM companies WHERE deleted_at IS NULL AND type = \\$1 ORDER BY id").
+		WithArgs("LLC").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FETCH FORWARD 2 FROM company_export").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(1, "First Co", "", 10, true, "LLC", nil, nil, createdAt, createdAt).
+			AddRow(2, "Second, Co", "", 20, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectQuery("FETCH FORWARD 2 FROM company_export").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(3, "Third Co", "", 30, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectCommit()
+
+	stream := &exportStream{}
+	err := service.ExportCompanies(&proto.ExportCompaniesRequest{Type: "LLC", ChunkSize: 2}, stream)
+
+	assert.NoError(t, err)
+	assert.Len(t, stream.chunks, 2)
+	assert.Equal(t, "id,name,description,employees,registered,type,deleted_at,registration_number,created_at,updated_at\n"+
+		"1,First Co,,10,true,LLC,,,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n"+
+		"2,\"Second, Co\",,20,false,LLC,,,,\n", string(stream.chunks[0].GetData()))
+	assert.Equal(t, "3,Third Co,,30,false,LLC,,,,\n", string(stream.chunks[1].GetData()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportCompaniesJSONLAndColumnar(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+
+	for _, format := range []proto.ExportFormat{proto.ExportFormat_EXPORT_JSONL, proto.ExportFormat_EXPORT_COLUMNAR} {
+		mock.ExpectBegin()
+		mock.ExpectExec("DECLARE company_export").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("FETCH FORWARD 1000 FROM company_export").
+			WillReturnRows(sqlmock.NewRows(companyRowColumns).
+				AddRow(1, "First Co", "", 10, true, "LLC", nil, nil, nil, nil).
+				AddRow(2, "Second Co", "", 20, false, "LLC", nil, nil, nil, nil))
+		mock.ExpectCommit()
+
+		stream := &exportStream{}
+		assert.NoError(t, service.ExportCompanies(&proto.ExportCompaniesRequest{Format: format}, stream))
+		assert.Len(t, stream.chunks, 1)
+		assert.Equal(t, int32(2), stream.chunks[0].Rows)
+
+		if format == proto.ExportFormat_EXPORT_JSONL {
+			lines := strings.Split(strings.TrimSpace(string(stream.chunks[0].GetData())), "\n")
+			assert.Len(t, lines, 2)
+			var record importRecord
+			assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+			assert.Equal(t, "Second Co", record.Name)
+		} else {
+			columns := stream.chunks[0].GetColumns()
+			assert.Equal(t, []int64{1, 2}, columns.Id)
+			assert.Equal(t, []int32{10, 20}, columns.Employees)
+			assert.Equal(t, []int64{0, 0}, columns.DeletedAt)
+		}
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,86 @@
+package company
+
+import (
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *CompanyServiceImpl) GetCompanyHistory(ctx context.Context, req *proto.GetCompanyHistoryRequest) (*proto.GetCompanyHistoryResponse, error) {
+	query := `
+		SELECT version, company_id, name, description, employees, registered, type, valid_from, valid_to, registration_number
+		FROM companies_history
+		WHERE company_id = $1
+		ORDER BY version
+	`
+	rows, err := s.reader(ctx).QueryContext(ctx, query, req.Id)
+	if err != nil {
+		log.Printf("Failed to retrieve history of company with id %d: %v", req.Id, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &proto.GetCompanyHistoryResponse{}
+	previous := &proto.Company{Id: req.Id}
+	for rows.Next() {
+		var version proto.CompanyVersion
+		var company proto.Company
+		var validFrom time.Time
+		var validTo sql.NullTime
+		var registrationNumber sql.NullString
+		err := rows.Scan(&version.Version, &company.Id, &company.Name, &company.Description, &company.Employees,
+			&company.Registered, &company.Type, &validFrom, &validTo, &registrationNumber)
+		if err != nil {
+			log.Printf("Failed to scan history of company with id %d: %v", req.Id, err)
+			return nil, err
+		}
+
+		company.RegistrationNumber = registrationNumber.String
+		version.Company = &company
+		version.ValidFrom = timestamppb.New(validFrom)
+		if validTo.Valid {
+			version.ValidTo = timestamppb.New(validTo.Time)
+		}
+		version.Changes = diffCompanies(previous, &company)
+		resp.Versions = append(resp.Versions, &version)
+		previous = &company
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to retrieve history of company with id %d: %v", req.Id, err)
+		return nil, err
+	}
+
+	if len(resp.Versions) == 0 {
+		log.Printf("Company with id %d not found", req.Id)
+		return nil, sql.ErrNoRows
+	}
+	return resp, nil
+}
+
+// diffCompanies lists the fields whose value differs between two versions.
+func diffCompanies(before, after *proto.Company) []*proto.FieldChange {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"name", before.Name, after.Name},
+		{"description", before.Description, after.Description},
+		{"employees", strconv.Itoa(int(before.Employees)), strconv.Itoa(int(after.Employees))},
+		{"registered", strconv.FormatBool(before.Registered), strconv.FormatBool(after.Registered)},
+		{"type", before.Type, after.Type},
+		{"registration_number", before.RegistrationNumber, after.RegistrationNumber},
+	}
+
+	var changes []*proto.FieldChange
+	for _, field := range fields {
+		if field.before != field.after {
+			changes = append(changes, &proto.FieldChange{Field: field.name, OldValue: field.before, NewValue: field.after})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,358 @@
+package company
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/db"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxImportRejections caps how many rejected rows are listed in the report;
+// all of them are still counted.
+const maxImportRejections = 1000
+
+// importChunkSize is how many rows are applied per transaction, so an import
+// holds its locks only briefly however large the file is.
+const importChunkSize = 500
+
+func (s *CompanyServiceImpl) ImportCompanies(stream proto.CompanyService_ImportCompaniesServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "import options are required")
+	}
+	if err != nil {
+		return err
+	}
+	options := first.GetOptions()
+	if options == nil {
+		return status.Error(codes.InvalidArgument, "the first message must carry the import options")
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err == io.EOF {
+				writer.Close()
+				return
+			}
+			if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+			if msg.GetOptions() != nil {
+				writer.CloseWithError(status.Error(codes.InvalidArgument, "import options may only be sent once"))
+				return
+			}
+			if _, err := writer.Write(msg.GetData()); err != nil {
+				return
+			}
+		}
+	}()
+	defer reader.Close()
+
+	resp, err := s.Import(stream.Context(), reader, options)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(resp)
+}
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+type importItem struct {
+	row     int64
+	company *proto.Company
+}
+
+type importEvent struct {
+	eventType     string
+	before, after *proto.Company
+}
+
+func importRejection(row int64, err error) *proto.ImportRejection {
+	return &proto.ImportRejection{Row: row, Reason: status.Convert(err).Message()}
+}
+
+// importRun reads an import chunk by chunk and collects its report.
+type importRun struct {
+	rows       importReader
+	resp       *proto.ImportCompaniesResponse
+	rejections []*proto.ImportRejection
+	// next is the row after the last one read.
+	next int64
+}
+
+// read returns up to importChunkSize valid rows, rejecting the invalid ones
+// on the way, or no rows at the end of the input.
+func (run *importRun) read() ([]importItem, error) {
+	var items []importItem
+	for len(items) < importChunkSize {
+		row, company, err := run.rows.Next()
+		if err == io.EOF {
+			break
+		}
+		var invalid *invalidRowError
+		if errors.As(err, &invalid) {
+			run.rejections = append(run.rejections, importRejection(row, invalid.err))
+			run.next = row + 1
+			continue
+		}
+		if err != nil {
+			return items, err
+		}
+		run.next = row + 1
+
+		if err := validateCompany(company); err != nil {
+			run.rejections = append(run.rejections, importRejection(row, err))
+			continue
+		}
+		items = append(items, importItem{row: row, company: company})
+	}
+	return items, nil
+}
+
+// failedRow is the first row not imported when reading failed after items.
+func (run *importRun) failedRow(items []importItem) int64 {
+	if len(items) > 0 {
+		return items[0].row
+	}
+	return run.next
+}
+
+func (run *importRun) applied(events []importEvent, rejected []*proto.ImportRejection) {
+	for _, e := range events {
+		if e.eventType == "CREATE" {
+			run.resp.Created++
+		} else {
+			run.resp.Updated++
+		}
+	}
+	run.rejections = append(run.rejections, rejected...)
+}
+
+func (run *importRun) report() *proto.ImportCompaniesResponse {
+	rejections := run.rejections
+	sort.Slice(rejections, func(i, j int) bool { return rejections[i].Row < rejections[j].Row })
+	run.resp.Rejected = int64(len(rejections))
+	if len(rejections) > maxImportRejections {
+		rejections = rejections[:maxImportRejections]
+	}
+	run.resp.Rejections = rejections
+	return run.resp
+}
+
+// Import reads companies from r and creates or, in UPSERT mode, updates them.
+// Rows are read, validated and applied importChunkSize at a time, each chunk
+// in its own transaction that publishes its events once it commits. Invalid
+// rows are rejected without failing the import. If a chunk fails, the chunks
+// before it stay imported and Import returns the report so far, with
+// FailedRow set, together with an error status carrying it as a detail.
+//
+// A dry run applies all chunks in one transaction that is rolled back, so it
+// reports what a real import would do. It does not write audit entries, so
+// while the file is read it only holds the locks of the rows it touches.
+func (s *CompanyServiceImpl) Import(ctx context.Context, r io.Reader, options *proto.ImportOptions) (*proto.ImportCompaniesResponse, error) {
+	rows, err := newImportReader(r, options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &importRun{rows: rows, resp: &proto.ImportCompaniesResponse{DryRun: options.DryRun}, next: 1}
+	var failedRow int64
+	if options.DryRun {
+		failedRow, err = s.dryRunImport(ctx, run, options)
+	} else {
+		failedRow, err = s.applyImport(ctx, run, options)
+	}
+	resp := run.report()
+	if err != nil {
+		log.Printf("Failed to import companies from row %d after %d created, %d updated: %v", failedRow, resp.Created, resp.Updated, err)
+		resp.FailedRow = failedRow
+		st, detailErr := status.Convert(err).WithDetails(resp)
+		if detailErr != nil {
+			return resp, err
+		}
+		return resp, st.Err()
+	}
+	if !options.DryRun {
+		log.Printf("Imported companies: %d created, %d updated, %d rejected", resp.Created, resp.Updated, resp.Rejected)
+	}
+	return resp, nil
+}
+
+// applyImport imports the chunks of run one transaction at a time. On
+// failure it returns the first row that was not imported.
+func (s *CompanyServiceImpl) applyImport(ctx context.Context, run *importRun, options *proto.ImportOptions) (int64, error) {
+	for {
+		items, err := run.read()
+		if err != nil {
+			return run.failedRow(items), err
+		}
+		if len(items) == 0 {
+			return 0, nil
+		}
+
+		var events []importEvent
+		var rejected []*proto.ImportRejection
+		err = s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+			events, rejected = s.importChunk(ctx, items, options)
+			return nil
+		})
+		if err != nil {
+			return items[0].row, err
+		}
+		run.applied(events, rejected)
+		s.publishImportEvents(ctx, events)
+	}
+}
+
+// dryRunImport applies the chunks of run in a single transaction, each under
+// a savepoint, so that later chunks see the rows of earlier ones, and rolls
+// it back. The transaction is not retried as the input cannot be read twice.
+func (s *CompanyServiceImpl) dryRunImport(ctx context.Context, run *importRun, options *proto.ImportOptions) (int64, error) {
+	var failedRow int64
+	transactor := &db.Transactor{DB: s.DB}
+	err := transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		for {
+			items, err := run.read()
+			if err != nil {
+				failedRow = run.failedRow(items)
+				return err
+			}
+			if len(items) == 0 {
+				return errDryRun
+			}
+
+			var events []importEvent
+			var rejected []*proto.ImportRejection
+			err = withSavepoint(ctx, tx, func() error {
+				events, rejected = s.importChunk(ctx, items, options)
+				return nil
+			})
+			if err != nil {
+				failedRow = items[0].row
+				return err
+			}
+			run.applied(events, rejected)
+		}
+	})
+	if err == errDryRun {
+		return 0, nil
+	}
+	return failedRow, err
+}
+
+// importChunk applies items in the transaction in ctx, rejecting the rows
+// that fail.
+func (s *CompanyServiceImpl) importChunk(ctx context.Context, items []importItem, options *proto.ImportOptions) ([]importEvent, []*proto.ImportRejection) {
+	var events []importEvent
+	var rejected []*proto.ImportRejection
+	tx := db.QuerierFromContext(ctx, s.DB)
+	for _, item := range items {
+		var e importEvent
+		err := withSavepoint(ctx, tx, func() error {
+			var err error
+			e.eventType, e.before, e.after, err = s.importRow(ctx, item.company, options)
+			return err
+		})
+		if err != nil {
+			rejected = append(rejected, importRejection(item.row, err))
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, rejected
+}
+
+func (s *CompanyServiceImpl) publishImportEvents(ctx context.Context, events []importEvent) {
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, e := range events {
+		if e.eventType == "UPDATE" {
+			s.publishUpdate(ctx, e.before, e.after)
+		} else {
+			s.publishEvent(ctx, e.eventType, e.after)
+		}
+	}
+}
+
+// importRow returns the event type along with the company before (for
+// updates) and after the row was applied. Upserts match rows to companies by
+// registration number when the row has one and by name otherwise.
+func (s *CompanyServiceImpl) importRow(ctx context.Context, company *proto.Company, options *proto.ImportOptions) (string, *proto.Company, *proto.Company, error) {
+	tx := db.QuerierFromContext(ctx, s.DB)
+	// A dry run is rolled back, so it leaves the audit chain, and its lock,
+	// alone.
+	record := func(entry audit.Entry) error {
+		if options.DryRun {
+			return nil
+		}
+		return audit.Record(ctx, entry)
+	}
+	if options.Mode == proto.ImportMode_UPSERT {
+		key := naturalKey{field: KeyName, value: company.Name}
+		if company.RegistrationNumber != "" {
+			key = naturalKey{field: KeyRegistrationNumber, value: company.RegistrationNumber}
+		}
+		query := "SELECT " + companyColumns + " FROM companies WHERE " + key.condition(1) + " AND deleted_at IS NULL ORDER BY id LIMIT 1 FOR UPDATE"
+		before, err := scanCompany(tx.QueryRowContext(ctx, query, key.value))
+		if err == nil {
+			if err := s.checkUnique(ctx, before.Id, s.UniqueKeys.claims(company, before)); err != nil {
+				return "", nil, nil, err
+			}
+			query = `
+				UPDATE companies
+				SET name = $1, description = $2, employees = $3, registered = $4, type = $5,
+				    registration_number = COALESCE(NULLIF($6, ''), registration_number)
+				WHERE id = $7
+				RETURNING ` + companyColumns
+			after, err := scanCompany(tx.QueryRowContext(ctx, query, company.Name, company.Description, company.Employees,
+				company.Registered, company.Type, company.RegistrationNumber, before.Id))
+			if err != nil {
+				return "", nil, nil, err
+			}
+			err = record(audit.Entry{
+				CompanyID: after.Id,
+				Actor:     audit.ActorFromContext(ctx),
+				Action:    audit.ActionCompanyUpdate,
+				Before:    before,
+				After:     after,
+			})
+			return "UPDATE", before, after, err
+		}
+		if err != sql.ErrNoRows {
+			return "", nil, nil, err
+		}
+	}
+
+	if err := s.checkUnique(ctx, 0, s.UniqueKeys.claims(company, nil)); err != nil {
+		return "", nil, nil, err
+	}
+	query := `
+		INSERT INTO companies (name, description, employees, registered, type, registration_number)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING ` + companyColumns
+	created, err := scanCompany(tx.QueryRowContext(ctx, query, company.Name, company.Description, company.Employees,
+		company.Registered, company.Type, company.RegistrationNumber))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	err = record(audit.Entry{
+		CompanyID: created.Id,
+		Actor:     audit.ActorFromContext(ctx),
+		Action:    audit.ActionCompanyCreate,
+		After:     created,
+	})
+	return "CREATE", nil, created, err
+}
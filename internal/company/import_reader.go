@@ -0,0 +1,174 @@
+package company
+
+import (
+	"bufio"
+	"bytes"
+	"company-service/proto"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invalidRowError marks a row that could not be parsed; the import rejects
+// the row and carries on with the next one.
+type invalidRowError struct {
+	err error
+}
+
+func (e *invalidRowError) Error() string {
+	return e.err.Error()
+}
+
+type importReader interface {
+	// Next returns the next row number and company, or io.EOF when done.
+	Next() (int64, *proto.Company, error)
+}
+
+func newImportReader(r io.Reader, format proto.ImportFormat) (importReader, error) {
+	switch format {
+	case proto.ImportFormat_CSV:
+		return newCSVImportReader(r)
+	case proto.ImportFormat_JSONL:
+		return &jsonlImportReader{reader: bufio.NewReader(r)}, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported import format %s", format)
+	}
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	row     int64
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, status.Error(codes.InvalidArgument, "CSV import is empty")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not read CSV header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "id":
+			return nil, status.Error(codes.InvalidArgument, "CSV column \"id\" is not supported; upserts match companies by registration number or name")
+		case "name", "description", "employees", "registered", "type", "registration_number":
+			columns[name] = i
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown CSV column %q", name)
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, status.Error(codes.InvalidArgument, "CSV header must contain a name column")
+	}
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) Next() (int64, *proto.Company, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	r.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return r.row, nil, &invalidRowError{err: parseErr.Err}
+	}
+	if err != nil {
+		return r.row, nil, err
+	}
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	company := &proto.Company{
+		Name:               field("name"),
+		Description:        field("description"),
+		Type:               field("type"),
+		RegistrationNumber: field("registration_number"),
+	}
+	if employees := field("employees"); employees != "" {
+		n, err := strconv.ParseInt(employees, 10, 32)
+		if err != nil {
+			return r.row, nil, &invalidRowError{err: fmt.Errorf("invalid employees %q", employees)}
+		}
+		company.Employees = int32(n)
+	}
+	if registered := field("registered"); registered != "" {
+		b, err := strconv.ParseBool(registered)
+		if err != nil {
+			return r.row, nil, &invalidRowError{err: fmt.Errorf("invalid registered %q", registered)}
+		}
+		company.Registered = b
+	}
+	return r.row, company, nil
+}
+
+type jsonlImportReader struct {
+	reader *bufio.Reader
+	row    int64
+}
+
+type importRecord struct {
+	ID                 json.RawMessage `json:"id"`
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	Employees          int32           `json:"employees"`
+	Registered         bool            `json:"registered"`
+	Type               string          `json:"type"`
+	RegistrationNumber string          `json:"registration_number"`
+}
+
+func (r *jsonlImportReader) Next() (int64, *proto.Company, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return r.row, nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		r.row++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var record importRecord
+		if jsonErr := json.Unmarshal(line, &record); jsonErr != nil {
+			return r.row, nil, &invalidRowError{err: fmt.Errorf("invalid JSON: %v", jsonErr)}
+		}
+		// Like the CSV id column, this stops the import rather than the row.
+		if record.ID != nil {
+			return r.row, nil, status.Errorf(codes.InvalidArgument, "row %d: field \"id\" is not supported; upserts match companies by registration number or name", r.row)
+		}
+		return r.row, &proto.Company{
+			Name:               record.Name,
+			Description:        record.Description,
+			Employees:          record.Employees,
+			Registered:         record.Registered,
+			Type:               record.Type,
+			RegistrationNumber: record.RegistrationNumber,
+		}, nil
+	}
+}
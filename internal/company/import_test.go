@@ -0,0 +1,204 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestImportCSVUpsert(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	input := "name,employees,registered,type\n" +
+		"Test Co,10,true,LLC\n" +
+		"New Co,5,false,Corporation\n" +
+		",3,true,LLC\n" +
+		"Bad Co,many,true,LLC\n"
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM companies WHERE lower\\(name\\) = lower\\(\\$1\\)").WithArgs("Test Co").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "test co", "", 2, false, "LLC", nil, nil, nil, nil))
+	mock.ExpectQuery("UPDATE companies").WithArgs("Test Co", "", 10, true, "LLC", "", int64(1)).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 10, true, "LLC", nil, nil, nil, nil))
+	expectAuditEntry(mock, int64(1), "anonymous", "COMPANY_UPDATE")
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("FROM companies WHERE lower\\(name\\) = lower\\(\\$1\\)").WithArgs("New Co").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns))
+	mock.ExpectQuery("INSERT INTO companies").WithArgs("New Co", "", 5, false, "Corporation", "").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(2, "New Co", "", 5, false, "Corporation", nil, nil, nil, nil))
+	expectAuditEntry(mock, int64(2), "anonymous", "COMPANY_CREATE")
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	resp, err := service.Import(context.Background(), strings.NewReader(input), &proto.ImportOptions{
+		Format: proto.ImportFormat_CSV,
+		Mode:   proto.ImportMode_UPSERT,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Created)
+	assert.Equal(t, int64(1), resp.Updated)
+	assert.Equal(t, int64(2), resp.Rejected)
+	assert.Equal(t, int64(3), resp.Rejections[0].Row)
+	assert.Equal(t, "name is required", resp.Rejections[0].Reason)
+	assert.Equal(t, int64(4), resp.Rejections[1].Row)
+	assert.Len(t, kafkaProducer.Messages(), 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJSONLDryRun(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	input := `{"name": "Test Co", "employees": 10}` + "\n\n" + `{"name": ` + "\n"
+
+	// The dry run writes no audit entries.
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO companies").WithArgs("Test Co", "", 10, false, "", "").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(1, "Test Co", "", 10, false, "", nil, nil, nil, nil))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	resp, err := service.Import(context.Background(), strings.NewReader(input), &proto.ImportOptions{
+		Format: proto.ImportFormat_JSONL,
+		DryRun: true,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.DryRun)
+	assert.Equal(t, int64(1), resp.Created)
+	assert.Equal(t, int64(1), resp.Rejected)
+	assert.Equal(t, int64(3), resp.Rejections[0].Row)
+	assert.Empty(t, kafkaProducer.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportCommitsEachChunk(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	var input strings.Builder
+	for i := 1; i <= importChunkSize+1; i++ {
+		fmt.Fprintf(&input, `{"name": "Co %d"}`+"\n", i)
+	}
+
+	mock.ExpectBegin()
+	for i := 1; i <= importChunkSize; i++ {
+		mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("INSERT INTO companies").WithArgs(fmt.Sprintf("Co %d", i), "", 0, false, "", "").
+			WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(i, fmt.Sprintf("Co %d", i), "", 0, false, "", nil, nil, nil, nil))
+		expectAuditEntry(mock, int64(i), "anonymous", "COMPANY_CREATE")
+		mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+	mock.ExpectBegin().WillReturnError(errors.New("connection lost"))
+
+	resp, err := service.Import(context.Background(), strings.NewReader(input.String()), &proto.ImportOptions{
+		Format: proto.ImportFormat_JSONL,
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, int64(importChunkSize), resp.Created)
+	assert.Equal(t, int64(importChunkSize+1), resp.FailedRow)
+	details := status.Convert(err).Details()
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, int64(importChunkSize+1), details[0].(*proto.ImportCompaniesResponse).FailedRow)
+	}
+	assert.Len(t, kafkaProducer.Messages(), importChunkSize)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportDryRunSpansChunksInOneTransaction(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	var input strings.Builder
+	for i := 1; i <= importChunkSize+1; i++ {
+		fmt.Fprintf(&input, `{"name": "Co %d"}`+"\n", i)
+	}
+
+	mock.ExpectBegin()
+	for i := 1; i <= importChunkSize+1; i++ {
+		if i == 1 || i == importChunkSize+1 {
+			mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectExec("SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("INSERT INTO companies").WithArgs(fmt.Sprintf("Co %d", i), "", 0, false, "", "").
+			WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(i, fmt.Sprintf("Co %d", i), "", 0, false, "", nil, nil, nil, nil))
+		mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		if i == importChunkSize || i == importChunkSize+1 {
+			mock.ExpectExec("RELEASE SAVEPOINT batch_item").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+	mock.ExpectRollback()
+
+	resp, err := service.Import(context.Background(), strings.NewReader(input.String()), &proto.ImportOptions{
+		Format: proto.ImportFormat_JSONL,
+		DryRun: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(importChunkSize+1), resp.Created)
+	assert.Empty(t, kafkaProducer.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportCSVRejectsIDColumn(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafka.NewFake())
+
+	_, err := service.Import(context.Background(), strings.NewReader("id,name\n1,Test Co\n"), &proto.ImportOptions{
+		Format: proto.ImportFormat_CSV,
+		Mode:   proto.ImportMode_UPSERT,
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportJSONLRejectsID(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafka.NewFake())
+
+	resp, err := service.Import(context.Background(), strings.NewReader(`{"id": 1, "name": "Test Co"}`+"\n"), &proto.ImportOptions{
+		Format: proto.ImportFormat_JSONL,
+		Mode:   proto.ImportMode_UPSERT,
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int64(1), resp.FailedRow)
+	assert.Zero(t, resp.Created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
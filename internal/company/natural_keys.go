@@ -0,0 +1,256 @@
+package company
+
+import (
+	"company-service/internal/db"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Natural keys of a company that can be made unique.
+const (
+	KeyName               = "name"
+	KeyRegistrationNumber = "registration_number"
+)
+
+// naturalKeyLock namespaces the advisory locks that keep two transactions
+// from claiming the same natural key at once.
+const naturalKeyLock = 7250013
+
+// uniqueIndexes back each natural key with a partial unique index, so that
+// the database rejects duplicates the advisory locks cannot see, such as
+// rows written by other clients.
+var uniqueIndexes = []struct {
+	key, name, definition string
+}{
+	{KeyName, "companies_name_key", "ON companies (lower(name)) WHERE deleted_at IS NULL"},
+	{KeyRegistrationNumber, "companies_registration_number_key", "ON companies (registration_number) WHERE deleted_at IS NULL"},
+}
+
+// UniqueKeys selects the natural keys that must be unique among companies
+// that are not deleted. Names are compared case insensitively; empty values
+// are never considered taken.
+type UniqueKeys struct {
+	Name               bool
+	RegistrationNumber bool
+}
+
+// ParseUniqueKeys parses a list of KeyName and KeyRegistrationNumber.
+func ParseUniqueKeys(keys []string) (UniqueKeys, error) {
+	var unique UniqueKeys
+	for _, key := range keys {
+		switch key {
+		case KeyName:
+			unique.Name = true
+		case KeyRegistrationNumber:
+			unique.RegistrationNumber = true
+		default:
+			return UniqueKeys{}, fmt.Errorf("unknown unique key %q", key)
+		}
+	}
+	return unique, nil
+}
+
+func (u UniqueKeys) has(key string) bool {
+	if key == KeyName {
+		return u.Name
+	}
+	return u.RegistrationNumber
+}
+
+// EnsureIndexes creates the unique indexes of the keys in u and drops those
+// of the other keys. It fails when live companies already share a key that
+// u makes unique.
+func (u UniqueKeys) EnsureIndexes(ctx context.Context, database *sql.DB) error {
+	return db.NewTransactor(database).WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, database)
+		// Replicas starting together would otherwise race to build the same index.
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", naturalKeyLock, "indexes"); err != nil {
+			return err
+		}
+		for _, index := range uniqueIndexes {
+			statement := "DROP INDEX IF EXISTS " + index.name
+			if u.has(index.key) {
+				statement = "CREATE UNIQUE INDEX IF NOT EXISTS " + index.name + " " + index.definition
+			}
+			_, err := tx.ExecContext(ctx, statement)
+			if db.IsUniqueViolation(err) {
+				return fmt.Errorf("live companies share a %s, rename or delete the duplicates first: %w", index.key, err)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// keyTaken turns a write rejected by one of the unique indexes into the
+// error checkUnique would have returned.
+func keyTaken(err error) error {
+	if db.IsUniqueViolation(err) {
+		return status.Error(codes.AlreadyExists, "another company already has this name or registration number")
+	}
+	return err
+}
+
+type naturalKey struct {
+	field string
+	value string
+}
+
+// condition matches the companies holding the key bound to placeholder.
+func (k naturalKey) condition(placeholder int) string {
+	if k.field == KeyName {
+		return fmt.Sprintf("lower(name) = lower($%d)", placeholder)
+	}
+	return fmt.Sprintf("registration_number = $%d", placeholder)
+}
+
+// lockName identifies the key independently of how a name is cased.
+func (k naturalKey) lockName() string {
+	if k.field == KeyName {
+		return k.field + ":" + strings.ToLower(k.value)
+	}
+	return k.field + ":" + k.value
+}
+
+// claims returns the unique keys company takes that it did not already hold
+// in before, which is nil for a company that is not live yet. Empty fields
+// of a partial update leave the key unchanged and claim nothing.
+func (u UniqueKeys) claims(company, before *proto.Company) []naturalKey {
+	var keys []naturalKey
+	if u.Name && company.Name != "" && (before == nil || !strings.EqualFold(company.Name, before.Name)) {
+		keys = append(keys, naturalKey{field: KeyName, value: company.Name})
+	}
+	if u.RegistrationNumber && company.RegistrationNumber != "" &&
+		(before == nil || company.RegistrationNumber != before.RegistrationNumber) {
+		keys = append(keys, naturalKey{field: KeyRegistrationNumber, value: company.RegistrationNumber})
+	}
+	return keys
+}
+
+// checkUnique fails with AlreadyExists when a live company other than id
+// holds one of the keys. The keys stay locked until the transaction carried
+// by ctx ends.
+func (s *CompanyServiceImpl) checkUnique(ctx context.Context, id int64, keys []naturalKey) error {
+	if err := s.lockNaturalKeys(ctx, keys); err != nil {
+		return err
+	}
+	return s.findKeyHolder(ctx, id, keys)
+}
+
+// lockNaturalKeys takes the locks in a fixed order so that transactions
+// claiming several keys cannot deadlock.
+func (s *CompanyServiceImpl) lockNaturalKeys(ctx context.Context, keys []naturalKey) error {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.lockName())
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		_, err := db.QuerierFromContext(ctx, s.DB).ExecContext(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", naturalKeyLock, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *CompanyServiceImpl) findKeyHolder(ctx context.Context, id int64, keys []naturalKey) error {
+	for _, key := range keys {
+		query := "SELECT id FROM companies WHERE " + key.condition(1) + " AND deleted_at IS NULL AND id <> $2 LIMIT 1"
+		var holder int64
+		err := db.QuerierFromContext(ctx, s.DB).QueryRowContext(ctx, query, key.value, id).Scan(&holder)
+		if err == nil {
+			return status.Errorf(codes.AlreadyExists, "company %d already has %s %q", holder, key.field, key.value)
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueRows fails the batch rows that claim a key held by another live
+// company or by an earlier row of the same batch, and returns the others.
+// before holds the current state of updated companies and is nil on create.
+func (s *CompanyServiceImpl) uniqueRows(ctx context.Context, b *batch, rows []int, companies []*proto.Company, before map[int64]*proto.Company) ([]int, error) {
+	claims := make(map[int][]naturalKey)
+	var keys []naturalKey
+	for _, row := range rows {
+		var current *proto.Company
+		if before != nil {
+			current = before[companies[row].Id]
+		}
+		claims[row] = s.UniqueKeys.claims(companies[row], current)
+		keys = append(keys, claims[row]...)
+	}
+	if len(keys) == 0 {
+		return rows, nil
+	}
+	if err := s.lockNaturalKeys(ctx, keys); err != nil {
+		log.Printf("Failed to lock company keys: %v", err)
+		return nil, err
+	}
+
+	claimedBy := make(map[string]int)
+	var unique []int
+	for _, row := range rows {
+		err := s.findKeyHolder(ctx, companies[row].Id, claims[row])
+		if err != nil && status.Code(err) != codes.AlreadyExists {
+			log.Printf("Failed to check company keys: %v", err)
+			return nil, err
+		}
+		for _, key := range claims[row] {
+			if earlier, ok := claimedBy[key.lockName()]; ok && err == nil {
+				err = status.Errorf(codes.AlreadyExists, "item %d already has %s %q", earlier, key.field, key.value)
+			}
+		}
+		if err != nil {
+			if err := b.fail(row, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, key := range claims[row] {
+			claimedBy[key.lockName()] = row
+		}
+		unique = append(unique, row)
+	}
+	return unique, nil
+}
+
+func (s *CompanyServiceImpl) GetCompanyByKey(ctx context.Context, req *proto.GetCompanyByKeyRequest) (*proto.GetCompanyResponse, error) {
+	var key naturalKey
+	switch k := req.Key.(type) {
+	case *proto.GetCompanyByKeyRequest_Name:
+		key = naturalKey{field: KeyName, value: k.Name}
+	case *proto.GetCompanyByKeyRequest_RegistrationNumber:
+		key = naturalKey{field: KeyRegistrationNumber, value: k.RegistrationNumber}
+	}
+	if key.value == "" {
+		return nil, status.Error(codes.InvalidArgument, "name or registration_number is required")
+	}
+
+	query := "SELECT " + companyColumns + " FROM companies WHERE " + key.condition(1) + " AND deleted_at IS NULL ORDER BY id LIMIT 1"
+	company, err := scanCompany(s.reader(ctx).QueryRowContext(ctx, query, key.value))
+	if err == sql.ErrNoRows {
+		return nil, status.Errorf(codes.NotFound, "no company has %s %q", key.field, key.value)
+	}
+	if err != nil {
+		log.Printf("Failed to retrieve company by %s: %v", key.field, err)
+		return nil, err
+	}
+	return &proto.GetCompanyResponse{Company: company}, nil
+}
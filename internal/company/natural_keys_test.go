@@ -0,0 +1,164 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseUniqueKeys(t *testing.T) {
+	keys, err := ParseUniqueKeys([]string{"name", "registration_number"})
+	assert.NoError(t, err)
+	assert.Equal(t, UniqueKeys{Name: true, RegistrationNumber: true}, keys)
+
+	keys, err = ParseUniqueKeys(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, UniqueKeys{}, keys)
+
+	_, err = ParseUniqueKeys([]string{"vat_id"})
+	assert.Error(t, err)
+}
+
+func TestUniqueKeysClaims(t *testing.T) {
+	unique := UniqueKeys{Name: true, RegistrationNumber: true}
+	before := &proto.Company{Id: 1, Name: "Acme Ltd", RegistrationNumber: "HRB 1"}
+
+	assert.Len(t, unique.claims(before, nil), 2)
+	assert.Empty(t, unique.claims(&proto.Company{Id: 1, Name: "ACME LTD"}, before))
+	assert.Equal(t, []naturalKey{{field: KeyRegistrationNumber, value: "HRB 2"}},
+		unique.claims(&proto.Company{Id: 1, RegistrationNumber: "HRB 2"}, before))
+	assert.Empty(t, UniqueKeys{}.claims(before, nil))
+}
+
+func TestCreateCompanyRejectsDuplicateName(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+	service.UniqueKeys = UniqueKeys{Name: true, RegistrationNumber: true}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock\\(\\$1, hashtext\\(\\$2\\)\\)").
+		WithArgs(naturalKeyLock, "name:acme ltd").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM companies WHERE lower\\(name\\) = lower\\(\\$1\\) AND deleted_at IS NULL AND id <> \\$2").
+		WithArgs("Acme Ltd", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectRollback()
+
+	_, err := service.CreateCompany(context.Background(), &proto.CreateCompanyRequest{Company: &proto.Company{Name: "Acme Ltd"}})
+
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Empty(t, kafkaProducer.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBatchCreateCompaniesRejectsDuplicatesWithinBatch(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafka.NewFake())
+	service.UniqueKeys = UniqueKeys{Name: true}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(naturalKeyLock, "name:acme ltd").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM companies WHERE lower\\(name\\)").WithArgs("Acme Ltd", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT id FROM companies WHERE lower\\(name\\)").WithArgs("ACME LTD", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := service.BatchCreateCompanies(context.Background(), &proto.BatchCreateCompaniesRequest{
+		Companies: []*proto.Company{{Name: "Acme Ltd"}, {Name: "ACME LTD"}},
+	})
+
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Contains(t, err.Error(), "item 1: item 0 already has name")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCompanyByKey(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+
+	mock.ExpectQuery("FROM companies WHERE registration_number = \\$1 AND deleted_at IS NULL ORDER BY id LIMIT 1").
+		WithArgs("HRB 12345").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).AddRow(3, "Acme Ltd", "", 10, true, "LLC", nil, "HRB 12345", nil, nil))
+	mock.ExpectQuery("FROM companies WHERE lower\\(name\\) = lower\\(\\$1\\)").
+		WithArgs("Nobody Ltd").
+		WillReturnRows(sqlmock.NewRows(companyRowColumns))
+
+	resp, err := service.GetCompanyByKey(context.Background(), &proto.GetCompanyByKeyRequest{
+		Key: &proto.GetCompanyByKeyRequest_RegistrationNumber{RegistrationNumber: "HRB 12345"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Company.Id)
+	assert.Equal(t, "HRB 12345", resp.Company.RegistrationNumber)
+
+	_, err = service.GetCompanyByKey(context.Background(), &proto.GetCompanyByKeyRequest{
+		Key: &proto.GetCompanyByKeyRequest_Name{Name: "Nobody Ltd"},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = service.GetCompanyByKey(context.Background(), &proto.GetCompanyByKeyRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnsureIndexesMatchesUniqueKeys(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs(naturalKeyLock, "indexes").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS companies_name_key ON companies \\(lower\\(name\\)\\) WHERE deleted_at IS NULL").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DROP INDEX IF EXISTS companies_registration_number_key").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.NoError(t, UniqueKeys{Name: true}.EnsureIndexes(context.Background(), db))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnsureIndexesFailsOnExistingDuplicates(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE UNIQUE INDEX IF NOT EXISTS companies_name_key").WillReturnError(sqlStateError("23505"))
+	mock.ExpectRollback()
+
+	err := UniqueKeys{Name: true, RegistrationNumber: true}.EnsureIndexes(context.Background(), db)
+
+	assert.ErrorContains(t, err, "live companies share a name")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateCompanyMapsUniqueViolation(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	kafkaProducer := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, kafkaProducer)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO companies").WillReturnError(sqlStateError("23505"))
+	mock.ExpectRollback()
+
+	_, err := service.CreateCompany(context.Background(), &proto.CreateCompanyRequest{Company: &proto.Company{Name: "Acme Ltd"}})
+
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	assert.Empty(t, kafkaProducer.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,172 @@
+package company
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/events"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const snapshotBatchSize = 500
+
+// RepublishSnapshot publishes a SNAPSHOT event with the current state of every
+// company matching the filters to the compacted snapshots topic, keyed by
+// company ID like the change events. Companies are published in id order and
+// progress is saved to the named checkpoint after every batch.
+func (s *CompanyServiceImpl) RepublishSnapshot(ctx context.Context, req *proto.RepublishSnapshotRequest) (*proto.RepublishSnapshotResponse, error) {
+	if s.SnapshotProducer == nil {
+		return nil, status.Error(codes.Unimplemented, "snapshot republishing is not enabled")
+	}
+	if req.Rate < 0 {
+		return nil, status.Error(codes.InvalidArgument, "rate must not be negative")
+	}
+
+	resp := &proto.RepublishSnapshotResponse{}
+	if req.Checkpoint != "" && !req.Restart {
+		afterID, err := s.loadSnapshotCheckpoint(ctx, req.Checkpoint)
+		if err != nil {
+			log.Printf("Failed to load snapshot checkpoint %s: %v", req.Checkpoint, err)
+			return nil, err
+		}
+		resp.ResumedAfterId = afterID
+	}
+	resp.LastId = resp.ResumedAfterId
+
+	var interval time.Duration
+	if req.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / req.Rate)
+	}
+	next := time.Now()
+	actor := audit.ActorFromContext(ctx)
+
+	for {
+		batch, err := s.snapshotBatch(ctx, req, resp.LastId)
+		if err != nil {
+			log.Printf("Failed to read companies for snapshot: %v", err)
+			return nil, err
+		}
+
+		for _, company := range batch {
+			if interval > 0 {
+				if wait := time.Until(next); wait > 0 {
+					select {
+					case <-ctx.Done():
+						s.saveSnapshotCheckpoint(ctx, req.Checkpoint, resp.LastId, false)
+						return nil, ctx.Err()
+					case <-time.After(wait):
+					}
+				} else {
+					next = time.Now()
+				}
+				next = next.Add(interval)
+			}
+
+			msg, err := s.snapshotMessage(company, req.IncludeDeleted, actor)
+			if err == nil {
+				err = s.SnapshotProducer.Publish(ctx, msg)
+			}
+			if err != nil {
+				log.Printf("Snapshot stopped after company %d: %v", resp.LastId, err)
+				s.saveSnapshotCheckpoint(ctx, req.Checkpoint, resp.LastId, false)
+				return nil, status.Errorf(codes.Unavailable, "published %d companies up to id %d: %v", resp.Published+resp.Tombstones, resp.LastId, err)
+			}
+
+			if msg.Value == nil {
+				resp.Tombstones++
+			} else {
+				resp.Published++
+			}
+			resp.LastId = company.Id
+		}
+
+		if len(batch) < snapshotBatchSize {
+			break
+		}
+		s.saveSnapshotCheckpoint(ctx, req.Checkpoint, resp.LastId, false)
+	}
+
+	s.saveSnapshotCheckpoint(ctx, req.Checkpoint, resp.LastId, true)
+	log.Printf("Republished snapshot of %d companies and %d tombstones", resp.Published, resp.Tombstones)
+	return resp, nil
+}
+
+// snapshotBatch reads the next companies after afterID. Soft-deleted
+// companies are always read so they can be tombstoned.
+func (s *CompanyServiceImpl) snapshotBatch(ctx context.Context, req *proto.RepublishSnapshotRequest, afterID int64) ([]*proto.Company, error) {
+	q := newCompanyQuery(nil)
+	q.where("id > $%d", afterID)
+	q.filter(req.Type, req.NameContains, true)
+	q.args = append(q.args, snapshotBatchSize)
+
+	rows, err := s.DB.QueryContext(ctx, fmt.Sprintf("%s ORDER BY id LIMIT $%d", q.sql(), len(q.args)), q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []*proto.Company
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, company)
+	}
+	return batch, rows.Err()
+}
+
+// snapshotMessage returns the SNAPSHOT event for company, or a tombstone
+// that removes it from the compacted topic when it is soft deleted.
+func (s *CompanyServiceImpl) snapshotMessage(company *proto.Company, includeDeleted bool, actor string) (kafka.Message, error) {
+	key := strconv.FormatInt(company.Id, 10)
+	if company.DeletedAt != nil && !includeDeleted {
+		return kafka.Message{Key: key}, nil
+	}
+
+	occurredAt := time.Now()
+	event, err := events.New("SNAPSHOT", company, actor, occurredAt)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return s.eventMessage(key, event, occurredAt)
+}
+
+// loadSnapshotCheckpoint returns the id a run using the checkpoint resumes
+// after: zero when it is new or its last run completed.
+func (s *CompanyServiceImpl) loadSnapshotCheckpoint(ctx context.Context, name string) (int64, error) {
+	var lastID int64
+	var completed bool
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT last_id, completed_at IS NOT NULL FROM snapshot_checkpoints WHERE name = $1",
+		name,
+	).Scan(&lastID, &completed)
+	if errors.Is(err, sql.ErrNoRows) || completed {
+		return 0, nil
+	}
+	return lastID, err
+}
+
+func (s *CompanyServiceImpl) saveSnapshotCheckpoint(ctx context.Context, name string, lastID int64, completed bool) {
+	if name == "" {
+		return
+	}
+	_, err := s.DB.ExecContext(context.WithoutCancel(ctx),
+		`INSERT INTO snapshot_checkpoints (name, last_id, completed_at, updated_at)
+		 VALUES ($1, $2, CASE WHEN $3 THEN NOW() END, NOW())
+		 ON CONFLICT (name) DO UPDATE SET last_id = EXCLUDED.last_id, completed_at = EXCLUDED.completed_at, updated_at = NOW()`,
+		name, lastID, completed,
+	)
+	if err != nil {
+		log.Printf("Failed to save snapshot checkpoint %s: %v", name, err)
+	}
+}
@@ -0,0 +1,52 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/events"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepublishSnapshotResumesFromCheckpoint(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	snapshots := kafka.NewFake()
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	service.SnapshotProducer = snapshots
+
+	mock.ExpectQuery("SELECT last_id, completed_at IS NOT NULL FROM snapshot_checkpoints").WithArgs("bootstrap").
+		WillReturnRows(sqlmock.NewRows([]string{"last_id", "completed"}).AddRow(4, false))
+	mock.ExpectQuery("SELECT .* FROM companies WHERE id > \\$1 AND type = \\$2 ORDER BY id LIMIT \\$3").
+		WithArgs(int64(4), "LLC", snapshotBatchSize).
+		WillReturnRows(sqlmock.NewRows(companyRowColumns).
+			AddRow(5, "Test Co", "", 5, false, "LLC", nil, nil, nil, nil).
+			AddRow(7, "Gone Co", "", 5, false, "LLC", time.Now(), nil, nil, nil))
+	mock.ExpectExec("INSERT INTO snapshot_checkpoints").WithArgs("bootstrap", int64(7), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := service.RepublishSnapshot(context.Background(), &proto.RepublishSnapshotRequest{
+		Type:       "LLC",
+		Checkpoint: "bootstrap",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), resp.ResumedAfterId)
+	assert.Equal(t, int64(7), resp.LastId)
+	assert.Equal(t, int64(1), resp.Published)
+	assert.Equal(t, int64(1), resp.Tombstones)
+
+	assert.Len(t, snapshots.Messages(), 2)
+	event, err := events.Decode(snapshots.Messages()[0].Value, snapshots.Messages()[0].Headers)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Co", event.(*proto.CompanySnapshot).Company.Name)
+	assert.Equal(t, "7", snapshots.Messages()[1].Key)
+	assert.Nil(t, snapshots.Messages()[1].Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
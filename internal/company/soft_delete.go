@@ -0,0 +1,181 @@
+package company
+
+import (
+	"company-service/internal/audit"
+	"company-service/internal/db"
+	"company-service/internal/kafka"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const purgeBatchSize = 500
+
+func (s *CompanyServiceImpl) RestoreCompany(ctx context.Context, req *proto.RestoreCompanyRequest) (*proto.RestoreCompanyResponse, error) {
+	var after *proto.Company
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		query := "SELECT " + companyColumns + " FROM companies WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE"
+		before, err := scanCompany(tx.QueryRowContext(ctx, query, req.Id))
+		if err == sql.ErrNoRows {
+			return status.Errorf(codes.NotFound, "no deleted company with id %d", req.Id)
+		}
+		if err != nil {
+			log.Printf("Failed to load deleted company with id %d: %v", req.Id, err)
+			return err
+		}
+
+		if err := s.checkUnique(ctx, req.Id, s.UniqueKeys.claims(before, nil)); err != nil {
+			log.Printf("Failed to restore company with id %d: %v", req.Id, err)
+			return err
+		}
+
+		query = "UPDATE companies SET deleted_at = NULL WHERE id = $1 RETURNING " + companyColumns
+		after, err = scanCompany(tx.QueryRowContext(ctx, query, req.Id))
+		if err != nil {
+			log.Printf("Failed to restore company with id %d: %v", req.Id, err)
+			return err
+		}
+
+		err = audit.Record(ctx, audit.Entry{
+			CompanyID: req.Id,
+			Actor:     audit.ActorFromContext(ctx),
+			Action:    audit.ActionCompanyRestore,
+			Before:    before,
+			After:     after,
+		})
+		if err != nil {
+			log.Printf("Failed to audit restore of company with id %d: %v", req.Id, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	s.publishEvent(ctx, "RESTORE", after)
+
+	return &proto.RestoreCompanyResponse{Company: after}, nil
+}
+
+// PurgeDeletedCompanies permanently removes companies that were soft deleted
+// more than retention ago and returns how many were purged.
+func (s *CompanyServiceImpl) PurgeDeletedCompanies(ctx context.Context, retention time.Duration) (int, error) {
+	purged := 0
+	for {
+		n, err := s.purgeBatch(ctx, retention)
+		purged += n
+		if err != nil || n < purgeBatchSize {
+			return purged, err
+		}
+	}
+}
+
+func (s *CompanyServiceImpl) purgeBatch(ctx context.Context, retention time.Duration) (int, error) {
+	var companies []*proto.Company
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		query := `
+			DELETE FROM companies
+			WHERE id IN (
+				SELECT id FROM companies
+				WHERE deleted_at < $1
+				ORDER BY id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + companyColumns
+		rows, err := db.QuerierFromContext(ctx, s.DB).QueryContext(ctx, query, time.Now().Add(-retention), purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		companies = nil
+		for rows.Next() {
+			company, err := scanCompany(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			companies = append(companies, company)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Snapshot runs can only tombstone companies whose rows still exist,
+		// so the tombstones go out before the rows are gone for good. A
+		// failure keeps the rows for the next run.
+		if err := s.publishTombstones(ctx, companies); err != nil {
+			return err
+		}
+
+		for _, company := range companies {
+			err := audit.Record(ctx, audit.Entry{
+				CompanyID: company.Id,
+				Actor:     audit.SystemActor("purge"),
+				Action:    audit.ActionCompanyPurge,
+				Before:    company,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, company := range companies {
+		s.publishEvent(ctx, "PURGED", company)
+	}
+	return len(companies), nil
+}
+
+// publishTombstones removes companies from the compacted snapshots topic.
+func (s *CompanyServiceImpl) publishTombstones(ctx context.Context, companies []*proto.Company) error {
+	if s.SnapshotProducer == nil {
+		return nil
+	}
+	ctx, cancel := s.publishContext(ctx)
+	defer cancel()
+	for _, company := range companies {
+		if err := s.SnapshotProducer.Publish(ctx, kafka.Message{Key: strconv.FormatInt(company.Id, 10)}); err != nil {
+			log.Printf("Failed to publish snapshot tombstone for company %d: %v", company.Id, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RunPurgeJob purges expired soft-deleted companies every interval until ctx is done.
+func (s *CompanyServiceImpl) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeletedCompanies(ctx, retention)
+			if err != nil {
+				log.Printf("Failed to purge deleted companies: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Purged %d companies deleted more than %s ago", purged, retention)
+			}
+		}
+	}
+}
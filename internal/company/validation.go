@@ -0,0 +1,55 @@
+package company
+
+import (
+	"company-service/proto"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxNameLength = 255
+	maxTypeLength = 50
+
+	maxRegistrationNumberLength = 64
+)
+
+// validateCompany checks a company about to be created.
+func validateCompany(company *proto.Company) error {
+	if company == nil {
+		return status.Error(codes.InvalidArgument, "company is required")
+	}
+	if strings.TrimSpace(company.Name) == "" {
+		return status.Error(codes.InvalidArgument, "name is required")
+	}
+	return validateCompanyFields(company)
+}
+
+// validateCompanyUpdate checks a partial update, where empty fields are left unchanged.
+func validateCompanyUpdate(company *proto.Company) error {
+	if company == nil {
+		return status.Error(codes.InvalidArgument, "company is required")
+	}
+	if company.Id <= 0 {
+		return status.Error(codes.InvalidArgument, "id is required")
+	}
+	return validateCompanyFields(company)
+}
+
+func validateCompanyFields(company *proto.Company) error {
+	if utf8.RuneCountInString(company.Name) > maxNameLength {
+		return status.Errorf(codes.InvalidArgument, "name must be at most %d characters", maxNameLength)
+	}
+	if utf8.RuneCountInString(company.Type) > maxTypeLength {
+		return status.Errorf(codes.InvalidArgument, "type must be at most %d characters", maxTypeLength)
+	}
+	if utf8.RuneCountInString(company.RegistrationNumber) > maxRegistrationNumberLength {
+		return status.Errorf(codes.InvalidArgument, "registration_number must be at most %d characters", maxRegistrationNumberLength)
+	}
+	if company.Employees < 0 {
+		return status.Error(codes.InvalidArgument, "employees must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package company
+
+import (
+	"company-service/internal/changefeed"
+	"company-service/proto"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const watchBatchSize = 500
+
+// WatchCompanies streams changes to companies as they are committed. Each
+// event carries a resume token; passing the last one received to a new watch
+// continues without gaps, as long as it is within the change feed retention.
+func (s *CompanyServiceImpl) WatchCompanies(req *proto.WatchCompaniesRequest, stream proto.CompanyService_WatchCompaniesServer) error {
+	if s.Changes == nil {
+		return status.Error(codes.Unimplemented, "the change feed is not enabled")
+	}
+	ctx := stream.Context()
+
+	filter := changefeed.Filter{
+		EventTypes:   req.EventTypes,
+		CompanyIDs:   req.CompanyIds,
+		Type:         req.Type,
+		NameContains: req.NameContains,
+	}
+
+	var after changefeed.Position
+	if req.ResumeToken != "" {
+		var err error
+		if after, err = changefeed.ParsePosition(req.ResumeToken); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid resume token %q", req.ResumeToken)
+		}
+		kept, err := s.Changes.Kept(ctx, after)
+		if err != nil {
+			log.Printf("Failed to read change feed: %v", err)
+			return err
+		}
+		if !kept {
+			return status.Errorf(codes.OutOfRange, "resume token %q has expired", req.ResumeToken)
+		}
+	} else {
+		var err error
+		if after, filter.Committed, err = s.Changes.Start(ctx); err != nil {
+			log.Printf("Failed to read change feed: %v", err)
+			return err
+		}
+	}
+
+	for {
+		changed := s.Changes.Changed()
+
+		changes, err := s.Changes.Since(ctx, after, filter, watchBatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("Failed to read change feed: %v", err)
+			return err
+		}
+		for _, change := range changes {
+			err := stream.Send(&proto.CompanyChangeEvent{
+				EventType:   change.EventType,
+				Company:     change.Company,
+				ResumeToken: change.Position().String(),
+				OccurredAt:  timestamppb.New(change.CreatedAt),
+			})
+			if err != nil {
+				return err
+			}
+			after = change.Position()
+		}
+		if len(changes) == watchBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
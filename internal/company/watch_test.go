@@ -0,0 +1,109 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/changefeed"
+	"company-service/proto"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	events []*proto.CompanyChangeEvent
+	max    int
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(event *proto.CompanyChangeEvent) error {
+	s.events = append(s.events, event)
+	if len(s.events) == s.max {
+		s.cancel()
+	}
+	return nil
+}
+
+var changeRowColumns = []string{"id", "txid", "event_type", "company", "previous", "created_at"}
+
+func TestWatchCompaniesResumes(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	service.Changes = changefeed.NewFeed(db)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM company_changes WHERE id = \\$1\\)").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	// Change 6 committed after change 7, in a transaction that started later.
+	mock.ExpectQuery("FROM company_changes WHERE \\(txid, id\\) > \\(\\$1, \\$2\\) .* ORDER BY txid, id").
+		WithArgs(int64(100), int64(5), watchBatchSize).
+		WillReturnRows(sqlmock.NewRows(changeRowColumns).
+			AddRow(7, 101, "CREATE", []byte(`{"id": 1, "name": "Test Co"}`), nil, time.Now()).
+			AddRow(6, 102, "UPDATE", []byte(`{"id": 1, "name": "Renamed Co"}`), []byte(`{"id": 1, "name": "Test Co"}`), time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, cancel: cancel, max: 2}
+	err := service.WatchCompanies(&proto.WatchCompaniesRequest{ResumeToken: "100:5"}, stream)
+
+	assert.NoError(t, err)
+	assert.Len(t, stream.events, 2)
+	assert.Equal(t, "CREATE", stream.events[0].EventType)
+	assert.Equal(t, "102:6", stream.events[1].ResumeToken)
+	assert.Equal(t, "Renamed Co", stream.events[1].Company.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchCompaniesSkipsChangesCommittedBeforeIt(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	service.Changes = changefeed.NewFeed(db)
+
+	// Transaction 100 is still running; 101 and 102 have committed.
+	mock.ExpectQuery("SELECT pg_snapshot_xmin\\(s\\)::text::bigint, s::text FROM pg_current_snapshot\\(\\) AS s").
+		WillReturnRows(sqlmock.NewRows([]string{"xmin", "snapshot"}).AddRow(100, "100:103:100"))
+	mock.ExpectQuery("NOT pg_visible_in_snapshot\\(txid::text::xid8, \\$3::pg_snapshot\\) ORDER BY txid, id").
+		WithArgs(int64(100), int64(0), "100:103:100", watchBatchSize).
+		WillReturnRows(sqlmock.NewRows(changeRowColumns).
+			AddRow(9, 100, "CREATE", []byte(`{"id": 1, "name": "Test Co"}`), nil, time.Now()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, cancel: cancel, max: 1}
+	err := service.WatchCompanies(&proto.WatchCompaniesRequest{}, stream)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "100:9", stream.events[0].ResumeToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchCompaniesRejectsExpiredToken(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	service.Changes = changefeed.NewFeed(db)
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM company_changes WHERE id = \\$1\\)").
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := service.WatchCompanies(&proto.WatchCompaniesRequest{ResumeToken: "5"}, &watchStream{ctx: ctx, cancel: cancel})
+
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
@@ -0,0 +1,220 @@
+package company
+
+import (
+	"company-service/internal/webhooks"
+	"company-service/proto"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var webhookEventTypes = map[string]bool{"CREATE": true, "UPDATE": true, "DELETE": true, "RESTORE": true, "PURGED": true}
+
+const minWebhookSecretLength = 16
+
+func (s *CompanyServiceImpl) CreateWebhook(ctx context.Context, req *proto.CreateWebhookRequest) (*proto.Webhook, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	eventTypes, err := s.validateWebhook(req.Url, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhooks.NewSecret(); err != nil {
+			return nil, err
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, status.Errorf(codes.InvalidArgument, "secret must be at least %d characters", minWebhookSecretLength)
+	}
+
+	sub, err := s.Webhooks.Create(ctx, req.Url, eventTypes, secret)
+	if err != nil {
+		log.Printf("Failed to create webhook: %v", err)
+		return nil, err
+	}
+	webhook := webhookToProto(sub)
+	webhook.Secret = sub.Secret
+	return webhook, nil
+}
+
+func (s *CompanyServiceImpl) UpdateWebhook(ctx context.Context, req *proto.UpdateWebhookRequest) (*proto.Webhook, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	eventTypes, err := s.validateWebhook(req.Url, req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.Webhooks.Update(ctx, req.Id, req.Url, eventTypes, req.Active, "disabled by UpdateWebhook")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "webhook %d not found", req.Id)
+	}
+	if err != nil {
+		log.Printf("Failed to update webhook %d: %v", req.Id, err)
+		return nil, err
+	}
+	return webhookToProto(sub), nil
+}
+
+func (s *CompanyServiceImpl) DeleteWebhook(ctx context.Context, req *proto.DeleteWebhookRequest) (*proto.DeleteWebhookResponse, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	deleted, err := s.Webhooks.Delete(ctx, req.Id)
+	if err != nil {
+		log.Printf("Failed to delete webhook %d: %v", req.Id, err)
+		return nil, err
+	}
+	if !deleted {
+		return nil, status.Errorf(codes.NotFound, "webhook %d not found", req.Id)
+	}
+	return &proto.DeleteWebhookResponse{}, nil
+}
+
+func (s *CompanyServiceImpl) ListWebhooks(ctx context.Context, req *proto.ListWebhooksRequest) (*proto.ListWebhooksResponse, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	subs, err := s.Webhooks.List(ctx)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		return nil, err
+	}
+
+	resp := &proto.ListWebhooksResponse{}
+	for _, sub := range subs {
+		resp.Webhooks = append(resp.Webhooks, webhookToProto(sub))
+	}
+	return resp, nil
+}
+
+func (s *CompanyServiceImpl) ListWebhookDeliveries(ctx context.Context, req *proto.ListWebhookDeliveriesRequest) (*proto.ListWebhookDeliveriesResponse, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	filter := webhooks.DeliveryFilter{
+		SubscriptionID: req.WebhookId,
+		Status:         strings.ToUpper(req.Status),
+		Limit:          int(req.PageSize),
+	}
+	switch filter.Status {
+	case "", webhooks.StatusPending, webhooks.StatusSucceeded, webhooks.StatusFailed:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown delivery status %q", req.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if req.PageToken != "" {
+		beforeID, err := strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token %q", req.PageToken)
+		}
+		filter.BeforeID = beforeID
+	}
+
+	deliveries, err := s.Webhooks.Deliveries(ctx, filter)
+	if err != nil {
+		log.Printf("Failed to list webhook deliveries: %v", err)
+		return nil, err
+	}
+
+	resp := &proto.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, deliveryToProto(delivery))
+	}
+	if len(deliveries) == filter.Limit {
+		resp.NextPageToken = strconv.FormatInt(deliveries[len(deliveries)-1].ID, 10)
+	}
+	return resp, nil
+}
+
+func (s *CompanyServiceImpl) RedeliverWebhook(ctx context.Context, req *proto.RedeliverWebhookRequest) (*proto.WebhookDelivery, error) {
+	if s.Webhooks == nil {
+		return nil, status.Error(codes.Unimplemented, "webhooks are not enabled")
+	}
+	delivery, err := s.Webhooks.Redeliver(ctx, req.DeliveryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "webhook delivery %d not found", req.DeliveryId)
+	}
+	if err != nil {
+		log.Printf("Failed to redeliver webhook delivery %d: %v", req.DeliveryId, err)
+		return nil, err
+	}
+	return deliveryToProto(delivery), nil
+}
+
+// validateWebhook checks the endpoint URL and returns the event types in
+// upper case.
+func (s *CompanyServiceImpl) validateWebhook(rawURL string, eventTypes []string) ([]string, error) {
+	if err := s.WebhookPolicy.CheckURL(rawURL); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.ToUpper(eventType)
+		if !webhookEventTypes[eventType] {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %q", eventType)
+		}
+		normalized = append(normalized, eventType)
+	}
+	return normalized, nil
+}
+
+func webhookToProto(sub *webhooks.Subscription) *proto.Webhook {
+	webhook := &proto.Webhook{
+		Id:                  sub.ID,
+		Url:                 sub.URL,
+		EventTypes:          sub.EventTypes,
+		Active:              sub.Active,
+		ConsecutiveFailures: int32(sub.ConsecutiveFailures),
+		DisabledReason:      sub.DisabledReason,
+		CreatedAt:           timestamppb.New(sub.CreatedAt),
+	}
+	if sub.DisabledAt.Valid {
+		webhook.DisabledAt = timestamppb.New(sub.DisabledAt.Time)
+	}
+	return webhook
+}
+
+func deliveryToProto(delivery *webhooks.Delivery) *proto.WebhookDelivery {
+	resp := &proto.WebhookDelivery{
+		Id:            delivery.ID,
+		WebhookId:     delivery.SubscriptionID,
+		EventId:       delivery.EventID,
+		EventType:     delivery.EventType,
+		CompanyId:     delivery.CompanyID,
+		Status:        delivery.Status,
+		Attempts:      int32(delivery.Attempts),
+		NextAttemptAt: timestamppb.New(delivery.NextAttemptAt),
+		LastError:     delivery.LastError,
+		CreatedAt:     timestamppb.New(delivery.CreatedAt),
+	}
+	if delivery.DeliveredAt.Valid {
+		resp.DeliveredAt = timestamppb.New(delivery.DeliveredAt.Time)
+	}
+	for _, attempt := range delivery.AttemptLog {
+		resp.AttemptLog = append(resp.AttemptLog, &proto.WebhookAttempt{
+			AttemptedAt: timestamppb.New(attempt.AttemptedAt),
+			StatusCode:  int32(attempt.StatusCode),
+			Error:       attempt.Error,
+			DurationMs:  attempt.Duration.Milliseconds(),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,55 @@
+package company
+
+import (
+	"company-service/internal/auth"
+	"company-service/internal/webhooks"
+	"company-service/proto"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateWebhookGeneratesSecret(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), db, nil)
+	service.Webhooks = webhooks.NewStore(db)
+
+	mock.ExpectQuery("INSERT INTO webhook_subscriptions").
+		WithArgs("https://partner.example.com/hooks", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types", "secret", "active", "consecutive_failures", "disabled_at", "disabled_reason", "created_at"}).
+			AddRow(1, "https://partner.example.com/hooks", "{CREATE,DELETE}", "whsec_generated", true, 0, nil, "", time.Now()))
+
+	webhook, err := service.CreateWebhook(context.Background(), &proto.CreateWebhookRequest{
+		Url:        "https://partner.example.com/hooks",
+		EventTypes: []string{"create", "DELETE"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE", "DELETE"}, webhook.EventTypes)
+	assert.True(t, strings.HasPrefix(webhook.Secret, "whsec_"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	service := NewCompanyServiceImpl(auth.NewAuthService("test-secret"), nil, nil)
+	service.Webhooks = webhooks.NewStore(nil)
+
+	for _, req := range []*proto.CreateWebhookRequest{
+		{Url: "ftp://partner.example.com"},
+		{Url: "http://partner.example.com"},
+		{Url: "https://169.254.169.254/latest/meta-data"},
+		{Url: "https://partner.example.com", EventTypes: []string{"SNAPSHOT"}},
+		{Url: "https://partner.example.com", Secret: "short"},
+	} {
+		_, err := service.CreateWebhook(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
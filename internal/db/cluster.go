@@ -0,0 +1,206 @@
+package db
+
+import (
+	config "company-service/configs"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LSN is a position in the Postgres write-ahead log.
+type LSN uint64
+
+// ParseLSN parses the X/Y form Postgres prints LSNs in.
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	high, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	low, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LSN %q", s)
+	}
+	return LSN(high<<32 | low), nil
+}
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint64(l)>>32, uint64(l)&0xFFFFFFFF)
+}
+
+// Replica is a read replica and the state of its last health check.
+type Replica struct {
+	Name string
+	DB   *sql.DB
+
+	mu      sync.Mutex
+	healthy bool
+	lag     time.Duration
+	lsn     LSN
+}
+
+func (r *Replica) state() (healthy bool, lag time.Duration, lsn LSN) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.healthy, r.lag, r.lsn
+}
+
+// check records whether the replica answers, how far behind the primary it
+// replays and up to which LSN. A streaming replica that has replayed
+// everything it received has no lag, however long ago the last write was.
+func (r *Replica) check(ctx context.Context) error {
+	query := `
+		SELECT pg_is_in_recovery(), COALESCE(pg_last_wal_replay_lsn()::text, ''),
+		       CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+		                 AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN 0
+		            ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END
+	`
+	var inRecovery bool
+	var replayed string
+	var lagSeconds float64
+	err := r.DB.QueryRowContext(ctx, query).Scan(&inRecovery, &replayed, &lagSeconds)
+	if err == nil && !inRecovery {
+		err = fmt.Errorf("replica %s is not in recovery", r.Name)
+	}
+	var lsn LSN
+	if err == nil {
+		lsn, err = ParseLSN(replayed)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.healthy = err == nil
+	if err == nil {
+		r.lag = time.Duration(lagSeconds * float64(time.Second))
+		r.lsn = lsn
+	}
+	return err
+}
+
+// Cluster routes reads to read replicas and everything else to the primary.
+// A read goes to the least busy replica that passed its last health check,
+// lags by at most MaxLag and has replayed the caller's writes (see
+// ContextWithReadAfter); without one it goes to the primary.
+type Cluster struct {
+	Primary  *sql.DB
+	Replicas []*Replica
+	MaxLag   time.Duration
+
+	next atomic.Uint64
+}
+
+// NewCluster returns a cluster over already opened pools. Replicas start
+// out unhealthy, so reads use the primary until the first Check.
+func NewCluster(primary *sql.DB, maxLag time.Duration, replicas ...*Replica) *Cluster {
+	return &Cluster{Primary: primary, Replicas: replicas, MaxLag: maxLag}
+}
+
+// ConnectReplicas opens a pool for each of cfg.DatabaseReplicaURLs, sized like
+// the primary's. Replicas are not pinged, so one that is down at startup only
+// stays out of rotation.
+func ConnectReplicas(cfg *config.Config) ([]*Replica, error) {
+	var replicas []*Replica
+	for i, replicaURL := range cfg.DatabaseReplicaURLs {
+		db, err := sql.Open("pgx", replicaURL)
+		if err != nil {
+			return nil, fmt.Errorf("could not open replica %d: %v", i+1, err)
+		}
+		configurePool(db, cfg)
+
+		name := fmt.Sprintf("replica %d", i+1)
+		if u, err := url.Parse(replicaURL); err == nil && u.Host != "" {
+			name = u.Host
+		}
+		replicas = append(replicas, &Replica{Name: name, DB: db})
+	}
+	return replicas, nil
+}
+
+// Reader returns the pool a read with ctx should use.
+func (c *Cluster) Reader(ctx context.Context) *sql.DB {
+	readAfter, _ := ReadAfterFromContext(ctx)
+
+	var best *Replica
+	bestInUse := 0
+	start := c.next.Add(1)
+	for i := range c.Replicas {
+		replica := c.Replicas[(start+uint64(i))%uint64(len(c.Replicas))]
+		healthy, lag, lsn := replica.state()
+		if !healthy || lag > c.MaxLag || lsn < readAfter {
+			continue
+		}
+		if inUse := replica.DB.Stats().InUse; best == nil || inUse < bestInUse {
+			best, bestInUse = replica, inUse
+		}
+	}
+	if best == nil {
+		return c.Primary
+	}
+	return best.DB
+}
+
+// PrimaryLSN returns the current write position of the primary.
+func (c *Cluster) PrimaryLSN(ctx context.Context) (LSN, error) {
+	var lsn string
+	if err := c.Primary.QueryRowContext(ctx, "SELECT pg_current_wal_lsn()::text").Scan(&lsn); err != nil {
+		return 0, err
+	}
+	return ParseLSN(lsn)
+}
+
+// Check runs a health check against every replica.
+func (c *Cluster) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, replica := range c.Replicas {
+		wg.Add(1)
+		go func(replica *Replica) {
+			defer wg.Done()
+			wasHealthy, _, _ := replica.state()
+			err := replica.check(ctx)
+			if err != nil && wasHealthy {
+				log.Printf("Replica %s failed its health check: %v", replica.Name, err)
+			} else if err == nil && !wasHealthy {
+				log.Printf("Replica %s passed its health check", replica.Name)
+			}
+		}(replica)
+	}
+	wg.Wait()
+}
+
+// RunHealthChecks checks the replicas every interval until ctx is done.
+func (c *Cluster) RunHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		c.Check(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the replica pools; the primary is left to its owner.
+func (c *Cluster) Close() error {
+	var firstErr error
+	for _, replica := range c.Replicas {
+		if err := replica.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := ParseLSN("16/B374D848")
+	assert.NoError(t, err)
+	assert.Equal(t, LSN(0x16B374D848), lsn)
+	assert.Equal(t, "16/B374D848", lsn.String())
+
+	_, err = ParseLSN("16B374D848")
+	assert.Error(t, err)
+	_, err = ParseLSN("16/XYZ")
+	assert.Error(t, err)
+}
+
+func newTestReplica(t *testing.T, name string) (*Replica, sqlmock.Sqlmock) {
+	db, mock, _ := sqlmock.New()
+	t.Cleanup(func() { db.Close() })
+	return &Replica{Name: name, DB: db}, mock
+}
+
+func expectReplicaCheck(mock sqlmock.Sqlmock, inRecovery bool, lsn string, lagSeconds float64) {
+	mock.ExpectQuery("SELECT pg_is_in_recovery\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"in_recovery", "lsn", "lag"}).AddRow(inRecovery, lsn, lagSeconds))
+}
+
+func TestReplicaCheck(t *testing.T) {
+	replica, mock := newTestReplica(t, "replica")
+
+	expectReplicaCheck(mock, true, "0/3000148", 1.5)
+	assert.NoError(t, replica.check(context.Background()))
+	healthy, lag, lsn := replica.state()
+	assert.True(t, healthy)
+	assert.Equal(t, 1500*time.Millisecond, lag)
+	assert.Equal(t, "0/3000148", lsn.String())
+
+	// A promoted replica would accept writes the primary never sees.
+	expectReplicaCheck(mock, false, "", 0)
+	assert.Error(t, replica.check(context.Background()))
+	healthy, _, _ = replica.state()
+	assert.False(t, healthy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClusterReader(t *testing.T) {
+	primary, _, _ := sqlmock.New()
+	defer primary.Close()
+	fresh, freshMock := newTestReplica(t, "fresh")
+	lagging, laggingMock := newTestReplica(t, "lagging")
+	down, downMock := newTestReplica(t, "down")
+
+	cluster := NewCluster(primary, 5*time.Second, fresh, lagging, down)
+	assert.Same(t, primary, cluster.Reader(context.Background()))
+
+	expectReplicaCheck(freshMock, true, "0/3000148", 0)
+	expectReplicaCheck(laggingMock, true, "0/3000148", 30)
+	downMock.ExpectQuery("SELECT pg_is_in_recovery\\(\\)").WillReturnError(sql.ErrConnDone)
+	cluster.Check(context.Background())
+
+	for i := 0; i < 3; i++ {
+		assert.Same(t, fresh.DB, cluster.Reader(context.Background()))
+	}
+	assert.Same(t, fresh.DB, cluster.Reader(ContextWithReadAfter(context.Background(), 0x3000148)))
+	assert.Same(t, primary, cluster.Reader(ContextWithReadAfter(context.Background(), 0x3000149)))
+}
+
+func TestClusterPrimaryLSN(t *testing.T) {
+	primary, mock, _ := sqlmock.New()
+	defer primary.Close()
+	mock.ExpectQuery("SELECT pg_current_wal_lsn\\(\\)::text").
+		WillReturnRows(sqlmock.NewRows([]string{"lsn"}).AddRow("0/3000148"))
+
+	lsn, err := NewCluster(primary, time.Second).PrimaryLSN(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, LSN(0x3000148), lsn)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadAfterFromMetadata(t *testing.T) {
+	ctx, err := readAfterFromMetadata(metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(MetadataReadAfter, "0/3000148")))
+	assert.NoError(t, err)
+	lsn, ok := ReadAfterFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, LSN(0x3000148), lsn)
+
+	ctx, err = readAfterFromMetadata(context.Background())
+	assert.NoError(t, err)
+	_, ok = ReadAfterFromContext(ctx)
+	assert.False(t, ok)
+
+	_, err = readAfterFromMetadata(metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(MetadataReadAfter, "latest")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"log"
 	_ "os"
-	"time"
 
 	_ "github.com/jackc/pgx/v4/stdlib" // PostgreSQL driver
 )
@@ -24,8 +23,7 @@ func Connect() (*sql.DB, error) {
 		return nil, fmt.Errorf("could not connect to the database: %v", err)
 	}
 
-	db.SetMaxOpenConns(10)
-	db.SetConnMaxLifetime(time.Hour)
+	configurePool(db, cfg)
 
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("could not ping the database: %v", err)
@@ -34,3 +32,10 @@ func Connect() (*sql.DB, error) {
 	log.Println("Connected to the database successfully")
 	return db, nil
 }
+
+func configurePool(db *sql.DB, cfg *config.Config) {
+	db.SetMaxOpenConns(cfg.DBMaxConnections)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConnections)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"company-service/db/migrations"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = fstest.MapFS{
+	"000002_add_b.up.sql":      {Data: []byte("ALTER TABLE a ADD COLUMN b TEXT;")},
+	"000002_add_b.down.sql":    {Data: []byte("ALTER TABLE a DROP COLUMN b;")},
+	"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT);")},
+	"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"README.md":                {Data: []byte("not a migration")},
+}
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+	for i, migration := range loaded {
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("SELECT pg_advisory_lock").WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMigratorUpAppliesPendingMigrations(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, testMigrations)
+	assert.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE a ADD COLUMN b TEXT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigratorDownRevertsToPreviousVersion(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, testMigrations)
+	assert.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("ALTER TABLE a DROP COLUMN b").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM schema_migrations").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	reverted, err := migrator.Down(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigratorRefusesDirtyDatabase(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, testMigrations)
+	assert.NoError(t, err)
+
+	expectLock(mock)
+	mock.ExpectQuery("SELECT version, dirty FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, true))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = migrator.Up(context.Background())
+
+	assert.ErrorContains(t, err, "dirty at version 1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
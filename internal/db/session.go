@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"log"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataReadAfter is the header carrying a read-your-writes session token:
+// the primary's LSN after a write, returned on mutations and sent back on
+// later reads so they only use replicas that have replayed the write.
+const MetadataReadAfter = "read-after-lsn"
+
+type readAfterKey struct{}
+
+// ContextWithReadAfter makes reads with the returned context wait for lsn,
+// using the primary until a replica has replayed it.
+func ContextWithReadAfter(ctx context.Context, lsn LSN) context.Context {
+	return context.WithValue(ctx, readAfterKey{}, lsn)
+}
+
+func ReadAfterFromContext(ctx context.Context) (LSN, bool) {
+	lsn, ok := ctx.Value(readAfterKey{}).(LSN)
+	return lsn, ok
+}
+
+// SessionInterceptor carries read-your-writes session tokens between the
+// client and the cluster. Responses of the write methods get the token in
+// their header; requests that send one get it into their context.
+type SessionInterceptor struct {
+	Cluster *Cluster
+	Writes  map[string]bool
+}
+
+func NewSessionInterceptor(cluster *Cluster, writeMethods ...string) *SessionInterceptor {
+	writes := make(map[string]bool, len(writeMethods))
+	for _, method := range writeMethods {
+		writes[method] = true
+	}
+	return &SessionInterceptor{Cluster: cluster, Writes: writes}
+}
+
+func (i *SessionInterceptor) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := readAfterFromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil || !i.Writes[path.Base(info.FullMethod)] {
+		return resp, err
+	}
+
+	// A missing token only costs the client read-your-writes, so the write
+	// still succeeds.
+	lsn, lsnErr := i.Cluster.PrimaryLSN(ctx)
+	if lsnErr != nil {
+		log.Printf("Failed to read primary LSN after %s: %v", info.FullMethod, lsnErr)
+		return resp, nil
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(MetadataReadAfter, lsn.String())); err != nil {
+		log.Printf("Failed to set %s header: %v", MetadataReadAfter, err)
+	}
+	return resp, nil
+}
+
+func (i *SessionInterceptor) StreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := readAfterFromMetadata(stream.Context())
+	if err != nil {
+		return err
+	}
+	if i.Writes[path.Base(info.FullMethod)] {
+		stream = &writeStream{ServerStream: stream, cluster: i.Cluster, method: info.FullMethod}
+	}
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+func readAfterFromMetadata(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(MetadataReadAfter)
+	if len(values) == 0 || values[0] == "" {
+		return ctx, nil
+	}
+	lsn, err := ParseLSN(values[0])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s header: %v", MetadataReadAfter, err)
+	}
+	return ContextWithReadAfter(ctx, lsn), nil
+}
+
+// contextStream overrides the context of a server stream so handlers see
+// values added by interceptors.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// writeStream sets the session token header right before the response of a
+// write stream is sent, that is after the handler has committed its writes.
+type writeStream struct {
+	grpc.ServerStream
+	cluster *Cluster
+	method  string
+	sent    bool
+}
+
+func (s *writeStream) SendMsg(m interface{}) error {
+	if !s.sent {
+		s.sent = true
+		lsn, err := s.cluster.PrimaryLSN(s.Context())
+		if err != nil {
+			log.Printf("Failed to read primary LSN after %s: %v", s.method, err)
+		} else if err := s.SetHeader(metadata.Pairs(MetadataReadAfter, lsn.String())); err != nil {
+			log.Printf("Failed to set %s header: %v", MetadataReadAfter, err)
+		}
+	}
+	return s.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Querier runs statements on a pool or inside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// ContextWithTx makes repositories called with the returned context run
+// their statements in tx.
+func ContextWithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// QuerierFromContext returns the transaction in ctx, or db outside of one.
+// Repositories use it so that callers decide whether their statements are
+// part of a larger transaction.
+func QuerierFromContext(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// Serialization failures and deadlocks abort a transaction that would have
+// succeeded on its own, so it is safe to run it again.
+var retryableStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+}
+
+// IsRetryable reports whether err aborted a transaction only because of a
+// concurrent one.
+func IsRetryable(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && retryableStates[pgErr.SQLState()]
+}
+
+// IsUniqueViolation reports whether err is a statement rejected by a unique
+// index.
+func IsUniqueViolation(err error) bool {
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+// Transactor runs functions in transactions on DB. A transaction aborted by
+// a serialization failure or deadlock is retried up to MaxAttempts times in
+// all, waiting InitialBackoff * 2^(n-1), capped at MaxBackoff and jittered,
+// before retry n.
+type Transactor struct {
+	DB             *sql.DB
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{DB: db, MaxAttempts: 5, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 500 * time.Millisecond}
+}
+
+// WithTx calls fn with a context carrying a new transaction, which is
+// committed when fn returns nil and rolled back otherwise. fn may run more
+// than once, so it must not have effects outside the transaction. Called
+// with a context that already carries a transaction, WithTx runs fn in it
+// and leaves opts, retries and the commit to the outer call.
+func (t *Transactor) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	attempts := t.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(t.backoff(attempt - 1)):
+			}
+		}
+		err = t.run(ctx, opts, fn)
+		if !IsRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (t *Transactor) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	tx, err := t.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback() //nolint:errcheck
+			panic(p)
+		}
+	}()
+
+	if err := fn(ContextWithTx(ctx, tx)); err != nil {
+		tx.Rollback() //nolint:errcheck
+		return err
+	}
+	return tx.Commit()
+}
+
+func (t *Transactor) backoff(retry int) time.Duration {
+	backoff := t.InitialBackoff << (retry - 1)
+	if backoff <= 0 || backoff > t.MaxBackoff {
+		backoff = t.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
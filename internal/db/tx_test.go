@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "SQLSTATE " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(sqlStateError("40001")))
+	assert.True(t, IsRetryable(fmt.Errorf("could not update: %w", sqlStateError("40P01"))))
+	assert.False(t, IsRetryable(sqlStateError("23505")))
+	assert.False(t, IsRetryable(errors.New("connection refused")))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestWithTxCommitsAndPropagatesTx(t *testing.T) {
+	database, mock, _ := sqlmock.New()
+	defer database.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	transactor := NewTransactor(database)
+	err := transactor.WithTx(context.Background(), nil, func(ctx context.Context) error {
+		tx, ok := TxFromContext(ctx)
+		assert.True(t, ok)
+		assert.Same(t, tx, QuerierFromContext(ctx, database))
+		if _, err := QuerierFromContext(ctx, database).ExecContext(ctx, "UPDATE a"); err != nil {
+			return err
+		}
+		// A nested call joins the outer transaction instead of beginning one.
+		return transactor.WithTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) error {
+			_, err := QuerierFromContext(ctx, database).ExecContext(ctx, "UPDATE b")
+			return err
+		})
+	})
+
+	assert.NoError(t, err)
+	assert.Same(t, database, QuerierFromContext(context.Background(), database))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxRetriesSerializationFailures(t *testing.T) {
+	database, mock, _ := sqlmock.New()
+	defer database.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnError(sqlStateError("40001"))
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit().WillReturnError(sqlStateError("40001"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	transactor := NewTransactor(database)
+	transactor.InitialBackoff, transactor.MaxBackoff = 0, 0
+	calls := 0
+	err := transactor.WithTx(context.Background(), nil, func(ctx context.Context) error {
+		calls++
+		_, err := QuerierFromContext(ctx, database).ExecContext(ctx, "UPDATE a")
+		return err
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTxGivesUp(t *testing.T) {
+	database, mock, _ := sqlmock.New()
+	defer database.Close()
+
+	transactor := NewTransactor(database)
+	transactor.MaxAttempts, transactor.InitialBackoff, transactor.MaxBackoff = 2, 0, 0
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+	}
+	err := transactor.WithTx(context.Background(), nil, func(ctx context.Context) error {
+		return sqlStateError("40P01")
+	})
+	assert.Equal(t, sqlStateError("40P01"), err)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	err = transactor.WithTx(context.Background(), nil, func(ctx context.Context) error {
+		return sqlStateError("23505")
+	})
+	assert.Equal(t, sqlStateError("23505"), err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
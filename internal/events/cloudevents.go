@@ -0,0 +1,208 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// CloudEventsMode selects the CloudEvents Kafka protocol binding mode.
+type CloudEventsMode string
+
+const (
+	// CloudEventsOff publishes plain events.
+	CloudEventsOff CloudEventsMode = ""
+	// CloudEventsBinary keeps the event as the message value and carries the
+	// attributes in ce_ headers.
+	CloudEventsBinary CloudEventsMode = "binary"
+	// CloudEventsStructured wraps the event and its attributes in a JSON
+	// envelope.
+	CloudEventsStructured CloudEventsMode = "structured"
+)
+
+const (
+	CloudEventsSpecVersion     = "1.0"
+	ContentTypeCloudEventsJSON = "application/cloudevents+json; charset=UTF-8"
+
+	cloudEventsHeaderPrefix = "ce_"
+)
+
+func ParseCloudEventsMode(s string) (CloudEventsMode, error) {
+	switch CloudEventsMode(strings.ToLower(s)) {
+	case CloudEventsOff, "off":
+		return CloudEventsOff, nil
+	case CloudEventsBinary:
+		return CloudEventsBinary, nil
+	case CloudEventsStructured:
+		return CloudEventsStructured, nil
+	default:
+		return "", fmt.Errorf("unknown CloudEvents mode %q", s)
+	}
+}
+
+// CloudEvents wraps encoded events in the CloudEvents Kafka binding.
+type CloudEvents struct {
+	Mode   CloudEventsMode
+	Source string // URI reference identifying this service, e.g. /company-service
+}
+
+// CloudEvent holds the attributes and data of a CloudEvent. It is also the
+// structured mode envelope.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	SchemaVersion   string          `json:"schemaversion,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+}
+
+// DataSchema returns the dataschema URI of an event type at the current
+// schema version.
+func DataSchema(eventType string) string {
+	return fmt.Sprintf("urn:company-service:proto:%s:v%s", eventType, SchemaVersion)
+}
+
+// Wrap turns an event encoded by Encode into a CloudEvent for company
+// subject occurring at the given time. With CloudEventsOff the event is
+// returned unchanged.
+func (c CloudEvents) Wrap(value []byte, headers map[string]string, subject string, at time.Time) ([]byte, map[string]string, error) {
+	if c.Mode == CloudEventsOff {
+		return value, headers, nil
+	}
+
+	event := CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              headers[HeaderEventID],
+		Source:          c.Source,
+		Type:            headers[HeaderEventType],
+		Subject:         subject,
+		Time:            at.UTC(),
+		DataSchema:      DataSchema(headers[HeaderEventType]),
+		DataContentType: headers[HeaderContentType],
+		SchemaVersion:   headers[HeaderSchemaVersion],
+	}
+
+	if event.ID == "" {
+		var err error
+		if event.ID, err = newEventID(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	switch c.Mode {
+	case CloudEventsBinary:
+		wrapped := map[string]string{
+			HeaderContentType:                         event.DataContentType,
+			cloudEventsHeaderPrefix + "specversion":   event.SpecVersion,
+			cloudEventsHeaderPrefix + "id":            event.ID,
+			cloudEventsHeaderPrefix + "source":        event.Source,
+			cloudEventsHeaderPrefix + "type":          event.Type,
+			cloudEventsHeaderPrefix + "subject":       event.Subject,
+			cloudEventsHeaderPrefix + "time":          event.Time.Format(time.RFC3339Nano),
+			cloudEventsHeaderPrefix + "dataschema":    event.DataSchema,
+			cloudEventsHeaderPrefix + "schemaversion": event.SchemaVersion,
+		}
+		return value, wrapped, nil
+
+	case CloudEventsStructured:
+		if event.DataContentType == ContentTypeJSON {
+			event.Data = value
+		} else {
+			event.DataBase64 = value
+		}
+		envelope, err := json.Marshal(event)
+		if err != nil {
+			return nil, nil, err
+		}
+		return envelope, map[string]string{HeaderContentType: ContentTypeCloudEventsJSON}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown CloudEvents mode %q", c.Mode)
+	}
+}
+
+// ParseCloudEvent reads a CloudEvent in either Kafka binding mode.
+func ParseCloudEvent(value []byte, headers map[string]string) (*CloudEvent, error) {
+	if strings.HasPrefix(headers[HeaderContentType], "application/cloudevents+json") {
+		var event CloudEvent
+		if err := json.Unmarshal(value, &event); err != nil {
+			return nil, fmt.Errorf("invalid structured CloudEvent: %v", err)
+		}
+		if event.SpecVersion != CloudEventsSpecVersion {
+			return nil, fmt.Errorf("unsupported CloudEvents spec version %q", event.SpecVersion)
+		}
+		return &event, nil
+	}
+
+	specVersion, ok := headers[cloudEventsHeaderPrefix+"specversion"]
+	if !ok {
+		return nil, fmt.Errorf("message is not a CloudEvent")
+	}
+	if specVersion != CloudEventsSpecVersion {
+		return nil, fmt.Errorf("unsupported CloudEvents spec version %q", specVersion)
+	}
+	event := &CloudEvent{
+		SpecVersion:     specVersion,
+		ID:              headers[cloudEventsHeaderPrefix+"id"],
+		Source:          headers[cloudEventsHeaderPrefix+"source"],
+		Type:            headers[cloudEventsHeaderPrefix+"type"],
+		Subject:         headers[cloudEventsHeaderPrefix+"subject"],
+		DataSchema:      headers[cloudEventsHeaderPrefix+"dataschema"],
+		DataContentType: headers[HeaderContentType],
+		SchemaVersion:   headers[cloudEventsHeaderPrefix+"schemaversion"],
+		DataBase64:      value,
+	}
+	if t := headers[cloudEventsHeaderPrefix+"time"]; t != "" {
+		var err error
+		if event.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return nil, fmt.Errorf("invalid CloudEvent time %q", t)
+		}
+	}
+	return event, nil
+}
+
+// EventID returns the id of an event published with or without a
+// CloudEvents envelope, or "" when it has none.
+func EventID(value []byte, headers map[string]string) string {
+	if id := headers[HeaderEventID]; id != "" {
+		return id
+	}
+	if event, err := ParseCloudEvent(value, headers); err == nil {
+		return event.ID
+	}
+	return ""
+}
+
+// Message decodes the event data into its protobuf message.
+func (e *CloudEvent) Message() (proto.Message, error) {
+	data := e.DataBase64
+	if data == nil {
+		data = e.Data
+	}
+	return Decode(data, map[string]string{
+		HeaderContentType:   e.DataContentType,
+		HeaderSchemaVersion: e.SchemaVersion,
+		HeaderEventType:     e.Type,
+	})
+}
+
+// newEventID returns a random (version 4) UUID.
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	pb "company-service/proto"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudEventsRoundTrip(t *testing.T) {
+	occurredAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	event, _ := New("CREATE", &pb.Company{Id: 42, Name: "Test Co"}, "user:7", occurredAt)
+
+	for _, mode := range []CloudEventsMode{CloudEventsBinary, CloudEventsStructured} {
+		for _, encoding := range []Encoding{EncodingProtobuf, EncodingJSON} {
+			value, headers, err := Encode(event, encoding)
+			assert.NoError(t, err)
+
+			value, headers, err = CloudEvents{Mode: mode, Source: "/company-service"}.Wrap(value, headers, "42", occurredAt)
+			assert.NoError(t, err)
+
+			parsed, err := ParseCloudEvent(value, headers)
+			assert.NoError(t, err)
+			assert.Equal(t, "1.0", parsed.SpecVersion)
+			assert.Len(t, parsed.ID, 36)
+			assert.Equal(t, "/company-service", parsed.Source)
+			assert.Equal(t, "company.CompanyCreated", parsed.Type)
+			assert.Equal(t, "42", parsed.Subject)
+			assert.Equal(t, "urn:company-service:proto:company.CompanyCreated:v1", parsed.DataSchema)
+			assert.True(t, occurredAt.Equal(parsed.Time))
+
+			message, err := parsed.Message()
+			assert.NoError(t, err)
+			assert.Equal(t, "Test Co", message.(*pb.CompanyCreated).Company.Name)
+		}
+	}
+}
+
+func TestCloudEventIDMatchesEventID(t *testing.T) {
+	event, _ := New("CREATE", &pb.Company{Id: 42}, "user:7", time.Now())
+	value, headers, _ := Encode(event, EncodingProtobuf)
+	eventID := headers[HeaderEventID]
+
+	_, headers, err := CloudEvents{Mode: CloudEventsBinary, Source: "/company-service"}.Wrap(value, headers, "42", time.Now())
+
+	assert.NoError(t, err)
+	assert.Len(t, eventID, 36)
+	assert.Equal(t, eventID, headers["ce_id"])
+}
+
+func TestStructuredCloudEventEmbedsJSONData(t *testing.T) {
+	event, _ := New("CREATE", &pb.Company{Id: 42, Name: "Test Co"}, "user:7", time.Now())
+	value, headers, _ := Encode(event, EncodingJSON)
+
+	value, headers, err := CloudEvents{Mode: CloudEventsStructured, Source: "/company-service"}.Wrap(value, headers, "42", time.Now())
+
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeCloudEventsJSON, headers[HeaderContentType])
+	var envelope map[string]interface{}
+	assert.NoError(t, json.Unmarshal(value, &envelope))
+	assert.Equal(t, "Test Co", envelope["data"].(map[string]interface{})["company"].(map[string]interface{})["name"])
+	assert.NotContains(t, envelope, "data_base64")
+}
+
+func TestParseCloudEventRejectsPlainEvents(t *testing.T) {
+	event, _ := New("CREATE", &pb.Company{Id: 42}, "user:7", time.Now())
+	value, headers, _ := Encode(event, EncodingProtobuf)
+
+	_, err := ParseCloudEvent(value, headers)
+
+	assert.Error(t, err)
+}
+
+func TestEventIDInEveryCloudEventsMode(t *testing.T) {
+	event, _ := New("CREATE", &pb.Company{Id: 42}, "user:7", time.Now())
+	value, headers, _ := Encode(event, EncodingJSON)
+	eventID := headers[HeaderEventID]
+	assert.Equal(t, eventID, EventID(value, headers))
+
+	for _, mode := range []CloudEventsMode{CloudEventsBinary, CloudEventsStructured} {
+		wrapped, wrappedHeaders, err := CloudEvents{Mode: mode, Source: "/company-service"}.Wrap(value, headers, "42", time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, eventID, EventID(wrapped, wrappedHeaders))
+	}
+}
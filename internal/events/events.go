@@ -0,0 +1,165 @@
+package events
+
+import (
+	pb "company-service/proto"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SchemaVersion is the version of the messages in proto/company_events.proto,
+// bumped only for changes that break their compatibility rules.
+const SchemaVersion = "1"
+
+// Headers set on every published event.
+const (
+	HeaderContentType   = "content-type"
+	HeaderSchemaVersion = "schema-version"
+	HeaderEventType     = "event-type"
+	// HeaderEventID is a unique id per event, kept across publish retries so
+	// consumers can drop duplicates.
+	HeaderEventID = "event-id"
+)
+
+const (
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeJSON     = "application/json"
+)
+
+// Encoding selects how event payloads are serialised.
+type Encoding string
+
+const (
+	EncodingProtobuf Encoding = "protobuf"
+	// EncodingJSON uses the proto3 JSON mapping of the same messages.
+	EncodingJSON Encoding = "json"
+)
+
+func ParseEncoding(s string) (Encoding, error) {
+	switch Encoding(strings.ToLower(s)) {
+	case EncodingProtobuf:
+		return EncodingProtobuf, nil
+	case EncodingJSON:
+		return EncodingJSON, nil
+	default:
+		return "", fmt.Errorf("unknown event encoding %q", s)
+	}
+}
+
+// New returns the event message for one of the service's event types:
+// CREATE, UPDATE, DELETE, RESTORE, PURGED or SNAPSHOT.
+func New(eventType string, company *pb.Company, actor string, occurredAt time.Time) (proto.Message, error) {
+	at := timestamppb.New(occurredAt)
+	switch eventType {
+	case "CREATE":
+		return &pb.CompanyCreated{Company: company, OccurredAt: at, Actor: actor}, nil
+	case "UPDATE":
+		return &pb.CompanyUpdated{Company: company, OccurredAt: at, Actor: actor}, nil
+	case "DELETE":
+		return &pb.CompanyDeleted{Company: company, OccurredAt: at, Actor: actor}, nil
+	case "RESTORE":
+		return &pb.CompanyRestored{Company: company, OccurredAt: at, Actor: actor}, nil
+	case "PURGED":
+		return &pb.CompanyPurged{Company: company, OccurredAt: at, Actor: actor}, nil
+	case "SNAPSHOT":
+		return &pb.CompanySnapshot{Company: company, OccurredAt: at, Actor: actor}, nil
+	default:
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+}
+
+// EventType returns the service event type of an event message, the
+// reverse of New.
+func EventType(event proto.Message) string {
+	switch event.(type) {
+	case *pb.CompanyCreated:
+		return "CREATE"
+	case *pb.CompanyUpdated:
+		return "UPDATE"
+	case *pb.CompanyDeleted:
+		return "DELETE"
+	case *pb.CompanyRestored:
+		return "RESTORE"
+	case *pb.CompanyPurged:
+		return "PURGED"
+	case *pb.CompanySnapshot:
+		return "SNAPSHOT"
+	default:
+		return ""
+	}
+}
+
+// NewUpdate returns the UPDATE event for a company that changed from before
+// to after.
+func NewUpdate(before, after *pb.Company, changedFields []string, actor string, occurredAt time.Time) proto.Message {
+	return &pb.CompanyUpdated{
+		Company:       after,
+		Before:        before,
+		ChangedFields: changedFields,
+		OccurredAt:    timestamppb.New(occurredAt),
+		Actor:         actor,
+	}
+}
+
+// Encode serialises event and returns the headers describing it.
+func Encode(event proto.Message, encoding Encoding) ([]byte, map[string]string, error) {
+	id, err := newEventID()
+	if err != nil {
+		return nil, nil, err
+	}
+	headers := map[string]string{
+		HeaderSchemaVersion: SchemaVersion,
+		HeaderEventType:     string(event.ProtoReflect().Descriptor().FullName()),
+		HeaderEventID:       id,
+	}
+
+	var value []byte
+	switch encoding {
+	case EncodingProtobuf:
+		headers[HeaderContentType] = ContentTypeProtobuf
+		value, err = proto.Marshal(event)
+	case EncodingJSON:
+		headers[HeaderContentType] = ContentTypeJSON
+		value, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(event)
+	default:
+		err = fmt.Errorf("unknown event encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, headers, nil
+}
+
+// Decode parses an event published by Encode, using its headers to find the
+// message type and encoding.
+func Decode(value []byte, headers map[string]string) (proto.Message, error) {
+	if version := headers[HeaderSchemaVersion]; version != SchemaVersion {
+		return nil, fmt.Errorf("unsupported event schema version %q", version)
+	}
+
+	eventType := headers[HeaderEventType]
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(eventType))
+	if err != nil {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	event := messageType.New().Interface()
+
+	switch headers[HeaderContentType] {
+	case ContentTypeProtobuf:
+		err = proto.Unmarshal(value, event)
+	case ContentTypeJSON:
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(value, event)
+	default:
+		err = fmt.Errorf("unsupported event content type %q", headers[HeaderContentType])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	pb "company-service/proto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	company := &pb.Company{Id: 1, Name: "Test Co", Employees: 10, Type: "LLC"}
+	occurredAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, encoding := range []Encoding{EncodingProtobuf, EncodingJSON} {
+		event, err := New("UPDATE", company, "user:7", occurredAt)
+		assert.NoError(t, err)
+
+		value, headers, err := Encode(event, encoding)
+		assert.NoError(t, err)
+		assert.Equal(t, "company.CompanyUpdated", headers[HeaderEventType])
+		assert.Equal(t, SchemaVersion, headers[HeaderSchemaVersion])
+
+		decoded, err := Decode(value, headers)
+		assert.NoError(t, err)
+		updated := decoded.(*pb.CompanyUpdated)
+		assert.Equal(t, "Test Co", updated.Company.Name)
+		assert.Equal(t, "user:7", updated.Actor)
+		assert.True(t, occurredAt.Equal(updated.OccurredAt.AsTime()))
+	}
+}
+
+func TestEncodeJSONUsesProtoFieldNames(t *testing.T) {
+	event, _ := New("CREATE", &pb.Company{Id: 1, Name: "Test Co"}, "anonymous", time.Now())
+
+	value, headers, err := Encode(event, EncodingJSON)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, headers[HeaderContentType])
+	assert.Contains(t, string(value), `"occurred_at"`)
+}
+
+func TestDecodeRejectsUnknownSchemaVersion(t *testing.T) {
+	_, err := Decode(nil, map[string]string{
+		HeaderSchemaVersion: "2",
+		HeaderEventType:     "company.CompanyCreated",
+		HeaderContentType:   ContentTypeProtobuf,
+	})
+
+	assert.Error(t, err)
+}
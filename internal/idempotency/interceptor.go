@@ -0,0 +1,337 @@
+package idempotency
+
+import (
+	"company-service/internal/audit"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MetadataKey is the request metadata header carrying the client's idempotency key.
+const MetadataKey = "idempotency-key"
+
+const maxKeyLength = 255
+
+const defaultLease = time.Minute
+
+const (
+	statusPending   = "PENDING"
+	statusCompleted = "COMPLETED"
+)
+
+// errLeaseLost reports that another request has taken over the key.
+var errLeaseLost = errors.New("idempotency key lease was lost")
+
+// Interceptor makes the configured methods safe to retry: the first request
+// with a given key runs and its response is stored for TTL, repeats with the
+// same payload get the stored response back without running again. While
+// the first request runs it holds the key for Lease and renews it; a request
+// that stops renewing, for instance because the process died, loses the key
+// to the next retry. Every claim carries its own token, so a request that
+// lost its key is cancelled and can no longer complete or release it.
+//
+// The response is stored after the handler has committed, so a crash in
+// between leaves the key to be taken over and the request runs again.
+type Interceptor struct {
+	DB      *sql.DB
+	TTL     time.Duration
+	Lease   time.Duration
+	Methods map[string]bool
+}
+
+func NewInterceptor(db *sql.DB, ttl time.Duration, methods ...string) *Interceptor {
+	methodSet := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		methodSet[method] = true
+	}
+	return &Interceptor{DB: db, TTL: ttl, Lease: defaultLease, Methods: methodSet}
+}
+
+type record struct {
+	requestHash  string
+	status       string
+	responseType string
+	response     []byte
+}
+
+func (i *Interceptor) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+
+	method := path.Base(info.FullMethod)
+	key := keyFromContext(ctx)
+	if key == "" || !i.Methods[method] {
+		return handler(ctx, req)
+	}
+	if len(key) > maxKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key must be at most %d characters", maxKeyLength)
+	}
+
+	message, ok := req.(proto.Message)
+	if !ok {
+		return handler(ctx, req)
+	}
+	requestHash, err := hashRequest(method, message)
+	if err != nil {
+		return nil, err
+	}
+	scope := principal(ctx)
+
+	token, err := i.claim(ctx, scope, key, method, requestHash)
+	if err != nil {
+		log.Printf("Failed to claim idempotency key %s: %v", key, err)
+		return nil, status.Error(codes.Unavailable, "could not check idempotency key")
+	}
+	if token == "" {
+		return i.replay(ctx, scope, key, requestHash)
+	}
+
+	// The key is settled even when the client has gone away meanwhile.
+	settleCtx := context.WithoutCancel(ctx)
+	handlerCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stopRenewing := i.renew(handlerCtx, scope, key, token, cancel)
+	resp, err := handler(handlerCtx, req)
+	stopRenewing()
+	if err != nil {
+		if context.Cause(handlerCtx) == errLeaseLost {
+			log.Printf("Lost idempotency key %s while its request ran: %v", key, err)
+			return nil, status.Error(codes.Aborted, "request with this idempotency key was taken over by a retry")
+		}
+		if releaseErr := i.release(settleCtx, scope, key, token); releaseErr != nil {
+			log.Printf("Failed to release idempotency key %s: %v", key, releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := i.complete(settleCtx, scope, key, token, resp); err != nil {
+		log.Printf("Failed to store response for idempotency key %s: %v", key, err)
+		if releaseErr := i.release(settleCtx, scope, key, token); releaseErr != nil {
+			log.Printf("Failed to release idempotency key %s: %v", key, releaseErr)
+		}
+	}
+	return resp, nil
+}
+
+// StreamInterceptor rejects idempotency keys on client-streaming calls, whose
+// requests are not known before they run and so cannot be replayed.
+func (i *Interceptor) StreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if info.IsClientStream && keyFromContext(stream.Context()) != "" {
+		return status.Errorf(codes.InvalidArgument, "%s does not support the %s header", path.Base(info.FullMethod), MetadataKey)
+	}
+	return handler(srv, stream)
+}
+
+// claim reserves the key for this request, taking it over from a request
+// whose lease or stored response has expired. It returns the token of the
+// new claim, or an empty token if the key is still held.
+func (i *Interceptor) claim(ctx context.Context, scope, key, method, requestHash string) (string, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return "", err
+	}
+	result, err := i.DB.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (principal, key, method, request_hash, status, claim_token, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW() + make_interval(secs => $7))
+		ON CONFLICT (principal, key) DO UPDATE
+		SET method = EXCLUDED.method, request_hash = EXCLUDED.request_hash, status = EXCLUDED.status,
+		    claim_token = EXCLUDED.claim_token, response_type = NULL, response = NULL,
+		    created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+	`, scope, key, method, requestHash, statusPending, token, i.Lease.Seconds())
+	if err != nil {
+		return "", err
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	if claimed == 0 {
+		return "", nil
+	}
+	return token, nil
+}
+
+// renew extends the lease of the claim every third of Lease until the
+// returned function is called. Once another request has taken the key over,
+// it cancels ctx with errLeaseLost so the handler does not commit.
+func (i *Interceptor) renew(ctx context.Context, scope, key, token string, cancel context.CancelCauseFunc) func() {
+	if i.Lease <= 0 {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(i.Lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := i.DB.ExecContext(ctx, `
+					UPDATE idempotency_keys SET expires_at = NOW() + make_interval(secs => $1)
+					WHERE principal = $2 AND key = $3 AND claim_token = $4 AND status = $5
+				`, i.Lease.Seconds(), scope, key, token, statusPending)
+				if err != nil {
+					log.Printf("Failed to renew idempotency key %s: %v", key, err)
+					continue
+				}
+				if renewed, err := result.RowsAffected(); err == nil && renewed == 0 {
+					cancel(errLeaseLost)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+func (i *Interceptor) replay(ctx context.Context, scope, key, requestHash string) (interface{}, error) {
+	var rec record
+	var responseType sql.NullString
+	err := i.DB.QueryRowContext(ctx, `
+		SELECT request_hash, status, response_type, response
+		FROM idempotency_keys
+		WHERE principal = $1 AND key = $2
+	`, scope, key).Scan(&rec.requestHash, &rec.status, &responseType, &rec.response)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key is being retried concurrently")
+	}
+	if err != nil {
+		log.Printf("Failed to load idempotency key %s: %v", key, err)
+		return nil, status.Error(codes.Unavailable, "could not check idempotency key")
+	}
+	rec.responseType = responseType.String
+
+	if rec.requestHash != requestHash {
+		return nil, status.Error(codes.FailedPrecondition, "idempotency key was already used with a different request")
+	}
+	if rec.status != statusCompleted {
+		return nil, status.Error(codes.Aborted, "request with this idempotency key is still in progress")
+	}
+
+	messageType, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(rec.responseType))
+	if err != nil {
+		return nil, fmt.Errorf("unknown stored response type %s: %v", rec.responseType, err)
+	}
+	resp := messageType.New().Interface()
+	if err := proto.Unmarshal(rec.response, resp); err != nil {
+		return nil, fmt.Errorf("could not decode stored response: %v", err)
+	}
+	log.Printf("Replayed stored response for idempotency key %s", key)
+	return resp, nil
+}
+
+func (i *Interceptor) complete(ctx context.Context, scope, key, token string, resp interface{}) error {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return fmt.Errorf("response %T is not a protobuf message", resp)
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	result, err := i.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status = $1, response_type = $2, response = $3, expires_at = NOW() + make_interval(secs => $6)
+		WHERE principal = $4 AND key = $5 AND claim_token = $7
+	`, statusCompleted, string(proto.MessageName(message)), data, scope, key, i.TTL.Seconds(), token)
+	if err != nil {
+		return err
+	}
+	completed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if completed == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// release forgets a key whose request failed so the client can retry it.
+func (i *Interceptor) release(ctx context.Context, scope, key, token string) error {
+	_, err := i.DB.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE principal = $1 AND key = $2 AND claim_token = $3", scope, key, token)
+	return err
+}
+
+// RunCleanup removes expired keys every interval until ctx is done.
+func (i *Interceptor) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := i.DB.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= NOW()"); err != nil {
+				log.Printf("Failed to remove expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
+func keyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(MetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func principal(ctx context.Context) string {
+	return audit.ActorFromContext(ctx)
+}
+
+func newClaimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashRequest(method string, req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("could not hash request: %v", err)
+	}
+	sum := sha256.Sum256(append([]byte(method+"\n"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
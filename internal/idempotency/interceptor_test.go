@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	companyproto "company-service/proto"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var createInfo = &grpc.UnaryServerInfo{FullMethod: "/company.CompanyService/CreateCompany"}
+
+func withKey(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, key))
+}
+
+func TestFirstRequestRunsAndStoresResponse(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	req := &companyproto.CreateCompanyRequest{Company: &companyproto.Company{Name: "Test Co"}}
+	hash, _ := hashRequest("CreateCompany", req)
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").
+		WithArgs("anonymous", "key-1", "CreateCompany", hash, statusPending, sqlmock.AnyArg(), float64(60)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE idempotency_keys").
+		WithArgs(statusCompleted, "company.CreateCompanyResponse", sqlmock.AnyArg(), "anonymous", "key-1", float64(3600), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &companyproto.CreateCompanyResponse{Company: &companyproto.Company{Id: 1, Name: "Test Co"}}, nil
+	}
+
+	resp, err := interceptor.UnaryInterceptor(withKey("key-1"), req, createInfo, handler)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.(*companyproto.CreateCompanyResponse).Company.Id)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepeatedRequestReplaysStoredResponse(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	req := &companyproto.CreateCompanyRequest{Company: &companyproto.Company{Name: "Test Co"}}
+	hash, _ := hashRequest("CreateCompany", req)
+	stored, _ := proto.Marshal(&companyproto.CreateCompanyResponse{Company: &companyproto.Company{Id: 7, Name: "Test Co"}})
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT request_hash, status, response_type, response FROM idempotency_keys").
+		WithArgs("anonymous", "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status", "response_type", "response"}).
+			AddRow(hash, statusCompleted, "company.CreateCompanyResponse", stored))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler must not run for a replayed request")
+		return nil, nil
+	}
+
+	resp, err := interceptor.UnaryInterceptor(withKey("key-1"), req, createInfo, handler)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), resp.(*companyproto.CreateCompanyResponse).Company.Id)
+}
+
+func TestReusedKeyWithDifferentPayloadIsRejected(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT request_hash").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status", "response_type", "response"}).
+			AddRow("other-hash", statusCompleted, "company.CreateCompanyResponse", []byte{}))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	req := &companyproto.CreateCompanyRequest{Company: &companyproto.Company{Name: "Other Co"}}
+
+	_, err := interceptor.UnaryInterceptor(withKey("key-1"), req, createInfo, nil)
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestRequestWithoutKeyIsNotTracked(t *testing.T) {
+	interceptor := NewInterceptor(nil, time.Hour, "CreateCompany")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	resp, err := interceptor.UnaryInterceptor(context.Background(), &companyproto.CreateCompanyRequest{}, createInfo, handler)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestKeyIsReleasedWhenResponseCannotBeStored(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE idempotency_keys").WillReturnError(sql.ErrConnDone)
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE principal = \\$1 AND key = \\$2 AND claim_token = \\$3$").
+		WithArgs("anonymous", "key-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &companyproto.CreateCompanyResponse{Company: &companyproto.Company{Id: 1}}, nil
+	}
+
+	resp, err := interceptor.UnaryInterceptor(withKey("key-1"), &companyproto.CreateCompanyRequest{}, createInfo, handler)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.(*companyproto.CreateCompanyResponse).Company.Id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestLosingItsKeyIsAborted(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE idempotency_keys SET expires_at").
+		WithArgs(float64(0.03), "anonymous", "key-1", sqlmock.AnyArg(), statusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	interceptor.Lease = 30 * time.Millisecond
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	_, err := interceptor.UnaryInterceptor(withKey("key-1"), &companyproto.CreateCompanyRequest{}, createInfo, handler)
+
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResponseIsNotStoredOverATakenOverKey(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE principal = \\$1 AND key = \\$2 AND claim_token = \\$3$").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	interceptor := NewInterceptor(db, time.Hour, "CreateCompany")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &companyproto.CreateCompanyResponse{Company: &companyproto.Company{Id: 1}}, nil
+	}
+
+	resp, err := interceptor.UnaryInterceptor(withKey("key-1"), &companyproto.CreateCompanyRequest{}, createInfo, handler)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.(*companyproto.CreateCompanyResponse).Company.Id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyKeyIsRejectedOnClientStreams(t *testing.T) {
+	interceptor := NewInterceptor(nil, time.Hour, "CreateCompany")
+	info := &grpc.StreamServerInfo{FullMethod: "/company.CompanyService/ImportCompanies", IsClientStream: true}
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		t.Fatal("handler must not run")
+		return nil
+	}
+
+	err := interceptor.StreamInterceptor(nil, &keyStream{ctx: withKey("key-1")}, info, handler)
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+type keyStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *keyStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/twmb/franz-go/pkg/kgo"
+	kgosasl "github.com/twmb/franz-go/pkg/sasl"
+)
+
+// Config holds the client settings shared by producers and consumers.
+type Config struct {
+	Brokers []string
+	TLS     *tls.Config    // Nil for plaintext connections
+	SASL    sasl.Mechanism // Nil when the brokers do not authenticate clients
+
+	RequiredAcks string // all, one or none
+	Compression  string // none, gzip, snappy, lz4 or zstd
+	// Idempotent has the brokers drop the duplicates the producer's own
+	// resends would otherwise write. It needs RequiredAcks all.
+	Idempotent bool
+}
+
+// NewTLSConfig loads the CA used to verify the brokers and, when certFile is
+// set, the client certificate for mutual TLS.
+func NewTLSConfig(caFile, certFile, keyFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecureSkipVerify} //nolint:gosec
+
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA file: %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// NewSASLMechanism returns the SASL mechanism named plain, scram-sha-256 or
+// scram-sha-512, or nil for none.
+func NewSASLMechanism(mechanism, username, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(mechanism) {
+	case "", "none":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unknown SASL mechanism %q", mechanism)
+	}
+}
+
+func (c Config) requiredAcks() (kgo.Acks, error) {
+	switch strings.ToLower(c.RequiredAcks) {
+	case "", "all":
+		return kgo.AllISRAcks(), nil
+	case "one":
+		return kgo.LeaderAck(), nil
+	case "none":
+		return kgo.NoAck(), nil
+	default:
+		return kgo.Acks{}, fmt.Errorf("unknown required acks %q", c.RequiredAcks)
+	}
+}
+
+func (c Config) compression() (kgo.CompressionCodec, error) {
+	switch strings.ToLower(c.Compression) {
+	case "", "none":
+		return kgo.NoCompression(), nil
+	case "gzip":
+		return kgo.GzipCompression(), nil
+	case "snappy":
+		return kgo.SnappyCompression(), nil
+	case "lz4":
+		return kgo.Lz4Compression(), nil
+	case "zstd":
+		return kgo.ZstdCompression(), nil
+	default:
+		return kgo.CompressionCodec{}, fmt.Errorf("unknown compression codec %q", c.Compression)
+	}
+}
+
+// producerOptions returns the settings of the franz-go producer, which unlike
+// kafka-go supports idempotent writes. Records are partitioned by key with
+// murmur2, like the Java client, so every event for a company lands on the
+// same partition in order.
+func (c Config) producerOptions() ([]kgo.Opt, error) {
+	if len(c.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+	acks, err := c.requiredAcks()
+	if err != nil {
+		return nil, err
+	}
+	compression, err := c.compression()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(c.Brokers...),
+		kgo.RequiredAcks(acks),
+		kgo.ProducerBatchCompression(compression),
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+		kgo.ProducerLinger(10 * time.Millisecond),
+	}
+	if c.Idempotent {
+		if acks != kgo.AllISRAcks() {
+			return nil, fmt.Errorf("idempotent writes need required acks all, not %q", c.RequiredAcks)
+		}
+	} else {
+		opts = append(opts, kgo.DisableIdempotentWrite())
+	}
+	if c.TLS != nil {
+		opts = append(opts, kgo.DialTLSConfig(c.TLS))
+	}
+	if c.SASL != nil {
+		opts = append(opts, kgo.SASL(producerSASL{c.SASL}))
+	}
+	return opts, nil
+}
+
+// producerSASL lets the franz-go producer authenticate with the kafka-go
+// mechanism the consumer uses.
+type producerSASL struct {
+	mechanism sasl.Mechanism
+}
+
+func (m producerSASL) Name() string {
+	return m.mechanism.Name()
+}
+
+func (m producerSASL) Authenticate(ctx context.Context, host string) (kgosasl.Session, []byte, error) {
+	state, response, err := m.mechanism.Start(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return producerSASLSession{ctx: ctx, state: state}, response, nil
+}
+
+type producerSASLSession struct {
+	ctx   context.Context
+	state sasl.StateMachine
+}
+
+func (s producerSASLSession) Challenge(challenge []byte) (bool, []byte, error) {
+	return s.state.Next(s.ctx, challenge)
+}
+
+// dialer returns the connection settings of consumers.
+func (c Config) dialer() *kafka.Dialer {
+	return &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, TLS: c.TLS, SASLMechanism: c.SASL}
+}
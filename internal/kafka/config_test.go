@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestRequiredAcks(t *testing.T) {
+	acks, err := Config{RequiredAcks: "one"}.requiredAcks()
+	assert.NoError(t, err)
+	assert.Equal(t, kgo.LeaderAck(), acks)
+
+	acks, err = Config{}.requiredAcks()
+	assert.NoError(t, err)
+	assert.Equal(t, kgo.AllISRAcks(), acks)
+
+	_, err = Config{RequiredAcks: "some"}.requiredAcks()
+	assert.Error(t, err)
+}
+
+func TestCompression(t *testing.T) {
+	codec, err := Config{Compression: "zstd"}.compression()
+	assert.NoError(t, err)
+	assert.Equal(t, kgo.ZstdCompression(), codec)
+
+	_, err = Config{Compression: "brotli"}.compression()
+	assert.Error(t, err)
+}
+
+func TestNewSASLMechanism(t *testing.T) {
+	for mechanism, name := range map[string]string{
+		"plain":         "PLAIN",
+		"SCRAM-SHA-256": "SCRAM-SHA-256",
+		"scram-sha-512": "SCRAM-SHA-512",
+	} {
+		m, err := NewSASLMechanism(mechanism, "user", "secret")
+		assert.NoError(t, err)
+		assert.Equal(t, name, m.Name())
+	}
+
+	m, err := NewSASLMechanism("none", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+
+	_, err = NewSASLMechanism("gssapi", "user", "secret")
+	assert.Error(t, err)
+}
+
+func TestNewKafkaProducer(t *testing.T) {
+	producer, err := NewKafkaProducer(Config{Brokers: []string{"a:9092", "b:9092"}, Idempotent: true}, "company_events")
+	if assert.NoError(t, err) {
+		assert.Equal(t, kgo.AllISRAcks(), producer.client.OptValue(kgo.RequiredAcks))
+		assert.Equal(t, false, producer.client.OptValue(kgo.DisableIdempotentWrite))
+		assert.Equal(t, "company_events", producer.client.OptValue(kgo.DefaultProduceTopic))
+
+		// Keys land on the partition the murmur2 hash of the Java client picks.
+		partitioner := producer.client.OptValue(kgo.RecordPartitioner).(kgo.Partitioner).ForTopic("company_events")
+		for _, key := range []string{"1", "42", "1042"} {
+			want := kafka.Murmur2Balancer{}.Balance(kafka.Message{Key: []byte(key)}, 0, 1, 2, 3, 4, 5)
+			assert.Equal(t, want, partitioner.Partition(&kgo.Record{Key: []byte(key)}, 6))
+		}
+		producer.Close()
+	}
+
+	_, err = NewKafkaProducer(Config{}, "company_events")
+	assert.Error(t, err)
+
+	_, err = NewKafkaProducer(Config{Brokers: []string{"a:9092"}, RequiredAcks: "one", Idempotent: true}, "company_events")
+	assert.Error(t, err)
+
+	producer, err = NewKafkaProducer(Config{Brokers: []string{"a:9092"}, RequiredAcks: "one"}, "company_events")
+	if assert.NoError(t, err) {
+		assert.Equal(t, true, producer.client.OptValue(kgo.DisableIdempotentWrite))
+		producer.Close()
+	}
+}
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer reads messages as a member of a consumer group. Offsets are only
+// committed through Commit, so a message that is never committed is
+// delivered again after a restart or rebalance.
+type Consumer interface {
+	Fetch(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, msg Message) error
+	Close() error
+}
+
+type KafkaConsumer struct {
+	reader *kafka.Reader
+}
+
+func NewKafkaConsumer(cfg Config, topic, groupID string) (*KafkaConsumer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+	if groupID == "" {
+		return nil, fmt.Errorf("a consumer group is required")
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: cfg.Brokers,
+		GroupID: groupID,
+		Topic:   topic,
+		Dialer:  cfg.dialer(),
+	})
+	return &KafkaConsumer{reader: reader}, nil
+}
+
+func (c *KafkaConsumer) Fetch(ctx context.Context) (Message, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return Message{
+		Key:       string(msg.Key),
+		Value:     msg.Value,
+		Headers:   headers,
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Time:      msg.Time,
+	}, nil
+}
+
+func (c *KafkaConsumer) Commit(ctx context.Context, msg Message) error {
+	return c.reader.CommitMessages(ctx, kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset})
+}
+
+func (c *KafkaConsumer) Close() error {
+	return c.reader.Close()
+}
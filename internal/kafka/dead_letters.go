@@ -0,0 +1,214 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetterQueue keeps messages that could not be published so they can be
+// replayed once the broker is back. Each dead letter records the sink it
+// failed on, so replaying it does not publish it again to the sinks that
+// already have it.
+type DeadLetterQueue interface {
+	Add(ctx context.Context, sink string, msg Message, cause error) error
+	// Replay publishes the dead letters in the order they were added to the
+	// producer of their sink in sinks, and removes each one that was
+	// published. Letters recorded without a sink are published to sinks[""].
+	// It stops at the first failure so events for a company are not
+	// reordered, and returns how many were replayed.
+	Replay(ctx context.Context, sinks map[string]Producer) (int, error)
+}
+
+func replayTo(ctx context.Context, sinks map[string]Producer, sink string, msg Message) error {
+	producer, ok := sinks[sink]
+	if !ok {
+		return fmt.Errorf("sink %q is not configured", sink)
+	}
+	return producer.Publish(ctx, msg)
+}
+
+// PostgresDeadLetters stores dead letters in the kafka_dead_letters table.
+type PostgresDeadLetters struct {
+	DB *sql.DB
+}
+
+func NewPostgresDeadLetters(db *sql.DB) *PostgresDeadLetters {
+	return &PostgresDeadLetters{DB: db}
+}
+
+func (q *PostgresDeadLetters) Add(ctx context.Context, sink string, msg Message, cause error) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+	_, err = q.DB.ExecContext(ctx,
+		"INSERT INTO kafka_dead_letters (sink, message_key, value, headers, error) VALUES ($1, $2, $3, $4, $5)",
+		sink, msg.Key, msg.Value, headers, cause.Error())
+	return err
+}
+
+const replayBatchSize = 100
+
+func (q *PostgresDeadLetters) Replay(ctx context.Context, sinks map[string]Producer) (int, error) {
+	replayed := 0
+	for {
+		rows, err := q.DB.QueryContext(ctx,
+			"SELECT id, sink, message_key, value, headers FROM kafka_dead_letters ORDER BY id LIMIT $1", replayBatchSize)
+		if err != nil {
+			return replayed, err
+		}
+
+		type deadLetter struct {
+			id   int64
+			sink string
+			msg  Message
+		}
+		var batch []deadLetter
+		for rows.Next() {
+			var letter deadLetter
+			var headers []byte
+			if err := rows.Scan(&letter.id, &letter.sink, &letter.msg.Key, &letter.msg.Value, &headers); err != nil {
+				rows.Close()
+				return replayed, err
+			}
+			if err := json.Unmarshal(headers, &letter.msg.Headers); err != nil {
+				rows.Close()
+				return replayed, fmt.Errorf("dead letter %d: %v", letter.id, err)
+			}
+			batch = append(batch, letter)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return replayed, err
+		}
+		if len(batch) == 0 {
+			return replayed, nil
+		}
+
+		for _, letter := range batch {
+			if err := replayTo(ctx, sinks, letter.sink, letter.msg); err != nil {
+				return replayed, fmt.Errorf("dead letter %d: %w", letter.id, err)
+			}
+			if _, err := q.DB.ExecContext(ctx, "DELETE FROM kafka_dead_letters WHERE id = $1", letter.id); err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+	}
+}
+
+// FileDeadLetters appends dead letters to a JSON Lines file.
+type FileDeadLetters struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileDeadLetters(path string) *FileDeadLetters {
+	return &FileDeadLetters{Path: path}
+}
+
+type fileDeadLetter struct {
+	Sink     string            `json:"sink,omitempty"`
+	Key      string            `json:"key"`
+	Value    []byte            `json:"value"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Error    string            `json:"error"`
+	FailedAt time.Time         `json:"failed_at"`
+}
+
+func (q *FileDeadLetters) Add(ctx context.Context, sink string, msg Message, cause error) error {
+	line, err := json.Marshal(fileDeadLetter{
+		Sink:     sink,
+		Key:      msg.Key,
+		Value:    msg.Value,
+		Headers:  msg.Headers,
+		Error:    cause.Error(),
+		FailedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return q.appendLines([][]byte{line})
+}
+
+func (q *FileDeadLetters) appendLines(lines [][]byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	file, err := os.OpenFile(q.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := file.Write(append(line, '\n')); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
+
+// Replay first moves the file aside, so a running service keeps appending
+// new dead letters to a fresh file. Letters that could not be replayed are
+// appended back to it.
+func (q *FileDeadLetters) Replay(ctx context.Context, sinks map[string]Producer) (int, error) {
+	replaying := q.Path + ".replaying"
+	if err := os.Rename(q.Path, replaying); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	file, err := os.Open(replaying)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	var replayErr error
+	for _, line := range lines {
+		var letter fileDeadLetter
+		if err := json.Unmarshal(line, &letter); err != nil {
+			replayErr = fmt.Errorf("dead letter %d: %v", replayed+1, err)
+			break
+		}
+		msg := Message{Key: letter.Key, Value: letter.Value, Headers: letter.Headers}
+		if err := replayTo(ctx, sinks, letter.Sink, msg); err != nil {
+			replayErr = fmt.Errorf("dead letter %d: %w", replayed+1, err)
+			break
+		}
+		replayed++
+	}
+
+	if remaining := lines[replayed:]; len(remaining) > 0 {
+		if err := q.appendLines(remaining); err != nil {
+			return replayed, fmt.Errorf("could not keep %d unreplayed dead letters in %s: %v", len(remaining), replaying, err)
+		}
+	}
+	if err := os.Remove(replaying); err != nil {
+		return replayed, err
+	}
+	return replayed, replayErr
+}
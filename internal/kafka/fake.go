@@ -0,0 +1,206 @@
+package kafka
+
+import (
+	"company-service/internal/events"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrFakeFailure is returned by Fake.Publish after FailNext when no other
+// error is given.
+var ErrFakeFailure = errors.New("fake publish failure")
+
+// Fake is an in-memory Producer and Consumer for tests. Published messages
+// are recorded with their time and offset, and are fetched by the consumer
+// side in the order they were published. It is safe for concurrent use.
+type Fake struct {
+	mu        sync.Mutex
+	messages  []Message
+	published chan struct{} // Closed and replaced on every publish
+	failures  int
+	failErr   error
+	blocked   chan struct{} // Publish waits while set, until it is closed
+	next      int
+	committed int64
+	closed    bool
+	resets    int // Bumped by Reset so waiters rescan from the start
+}
+
+func NewFake() *Fake {
+	return &Fake{published: make(chan struct{}), committed: -1}
+}
+
+func (f *Fake) Publish(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	blocked := f.blocked
+	f.mu.Unlock()
+	if blocked != nil {
+		select {
+		case <-blocked:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errors.New("fake producer is closed")
+	}
+	if f.failures > 0 {
+		f.failures--
+		return f.failErr
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	msg.Headers = headers
+	msg.Offset = int64(len(f.messages))
+	msg.Time = time.Now()
+	f.messages = append(f.messages, msg)
+
+	close(f.published)
+	f.published = make(chan struct{})
+	return nil
+}
+
+// FailNext makes the next n publishes fail with err, or ErrFakeFailure when
+// err is nil.
+func (f *Fake) FailNext(n int, err error) {
+	if err == nil {
+		err = ErrFakeFailure
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures, f.failErr = n, err
+}
+
+// Block makes publishes wait until Unblock is called or their context ends.
+func (f *Fake) Block() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.blocked == nil {
+		f.blocked = make(chan struct{})
+	}
+}
+
+func (f *Fake) Unblock() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.blocked != nil {
+		close(f.blocked)
+		f.blocked = nil
+	}
+}
+
+// Messages returns a copy of the published messages.
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
+
+// Reset forgets the published messages and consumer position.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages, f.next, f.committed = nil, 0, -1
+	f.resets++
+	close(f.published)
+	f.published = make(chan struct{})
+}
+
+// WaitForEvent waits until an event of eventType (CREATE, UPDATE, ...) for
+// the company has been published and returns it decoded. It also finds
+// events published before it was called.
+func (f *Fake) WaitForEvent(ctx context.Context, eventType string, companyID int64) (proto.Message, error) {
+	key := strconv.FormatInt(companyID, 10)
+	seen, resets := 0, 0
+	for {
+		f.mu.Lock()
+		if f.resets != resets {
+			seen, resets = 0, f.resets
+		}
+		messages := f.messages[seen:]
+		published := f.published
+		f.mu.Unlock()
+
+		for _, msg := range messages {
+			seen++
+			if msg.Key != key {
+				continue
+			}
+			event, err := decodeEvent(msg)
+			if err != nil {
+				return nil, err
+			}
+			if events.EventType(event) == eventType {
+				return event, nil
+			}
+		}
+
+		select {
+		case <-published:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func decodeEvent(msg Message) (proto.Message, error) {
+	if cloudEvent, err := events.ParseCloudEvent(msg.Value, msg.Headers); err == nil {
+		return cloudEvent.Message()
+	}
+	return events.Decode(msg.Value, msg.Headers)
+}
+
+// Fetch returns the next published message, waiting for one if needed.
+func (f *Fake) Fetch(ctx context.Context) (Message, error) {
+	for {
+		f.mu.Lock()
+		if f.next < len(f.messages) {
+			msg := f.messages[f.next]
+			f.next++
+			f.mu.Unlock()
+			return msg, nil
+		}
+		published := f.published
+		f.mu.Unlock()
+
+		select {
+		case <-published:
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		}
+	}
+}
+
+func (f *Fake) Commit(ctx context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if msg.Offset > f.committed {
+		f.committed = msg.Offset
+	}
+	return nil
+}
+
+// Committed returns the highest committed offset, or -1.
+func (f *Fake) Committed() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.committed
+}
+
+func (f *Fake) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"company-service/internal/events"
+	pb "company-service/proto"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventMessage(t *testing.T, eventType string, companyID int64) Message {
+	event, err := events.New(eventType, &pb.Company{Id: companyID, Name: "Test Co"}, "user:1", time.Now())
+	assert.NoError(t, err)
+	value, headers, err := events.Encode(event, events.EncodingProtobuf)
+	assert.NoError(t, err)
+	return Message{Key: strconv.FormatInt(companyID, 10), Value: value, Headers: headers}
+}
+
+func TestFakeRecordsConcurrentPublishes(t *testing.T) {
+	fake := NewFake()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, fake.Publish(context.Background(), Message{Key: strconv.Itoa(i), Headers: map[string]string{"n": strconv.Itoa(i)}}))
+		}()
+	}
+	wg.Wait()
+
+	messages := fake.Messages()
+	assert.Len(t, messages, 50)
+	for i, msg := range messages {
+		assert.Equal(t, int64(i), msg.Offset)
+		assert.Equal(t, msg.Key, msg.Headers["n"])
+		assert.False(t, msg.Time.IsZero())
+	}
+}
+
+func TestFakeFailNext(t *testing.T) {
+	fake := NewFake()
+	broken := errors.New("broker unavailable")
+	fake.FailNext(2, broken)
+
+	ctx := context.Background()
+	assert.Equal(t, broken, fake.Publish(ctx, Message{Key: "1"}))
+	assert.Equal(t, broken, fake.Publish(ctx, Message{Key: "1"}))
+	assert.NoError(t, fake.Publish(ctx, Message{Key: "1"}))
+	assert.Len(t, fake.Messages(), 1)
+}
+
+func TestFakeBlock(t *testing.T) {
+	fake := NewFake()
+	fake.Block()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, fake.Publish(ctx, Message{Key: "1"}), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		done <- fake.Publish(context.Background(), Message{Key: "2"})
+	}()
+	fake.Unblock()
+	assert.NoError(t, <-done)
+	assert.Len(t, fake.Messages(), 1)
+}
+
+func TestFakeWaitForEvent(t *testing.T) {
+	fake := NewFake()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, fake.Publish(ctx, eventMessage(t, "CREATE", 1)))
+	go func() {
+		_ = fake.Publish(context.Background(), eventMessage(t, "CREATE", 2))
+		_ = fake.Publish(context.Background(), eventMessage(t, "DELETE", 1))
+	}()
+
+	event, err := fake.WaitForEvent(ctx, "DELETE", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), event.(*pb.CompanyDeleted).Company.Id)
+
+	event, err = fake.WaitForEvent(ctx, "CREATE", 1)
+	assert.NoError(t, err)
+	assert.IsType(t, &pb.CompanyCreated{}, event)
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	_, err = fake.WaitForEvent(short, "UPDATE", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFakeWaitForEventAcrossReset(t *testing.T) {
+	fake := NewFake()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, fake.Publish(ctx, eventMessage(t, "CREATE", 2)))
+	assert.NoError(t, fake.Publish(ctx, eventMessage(t, "CREATE", 3)))
+	done := make(chan error, 1)
+	go func() {
+		_, err := fake.WaitForEvent(ctx, "DELETE", 1)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	fake.Reset()
+	assert.NoError(t, fake.Publish(ctx, eventMessage(t, "DELETE", 1)))
+	assert.NoError(t, <-done)
+}
+
+func TestFakeConsumer(t *testing.T) {
+	fake := NewFake()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	go func() {
+		_ = fake.Publish(context.Background(), Message{Key: "1"})
+		_ = fake.Publish(context.Background(), Message{Key: "2"})
+	}()
+
+	for _, key := range []string{"1", "2"} {
+		msg, err := fake.Fetch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, key, msg.Key)
+		assert.NoError(t, fake.Commit(ctx, msg))
+	}
+	assert.Equal(t, int64(1), fake.Committed())
+}
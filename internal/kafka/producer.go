@@ -5,41 +5,66 @@ import (
 	"log"
 	"time"
 
-	"github.com/segmentio/kafka-go"
+	"github.com/twmb/franz-go/pkg/kgo"
 )
 
+// Message is a record to publish or one that was consumed. Headers describe
+// how Value is encoded.
+type Message struct {
+	Key     string
+	Value   []byte
+	Headers map[string]string
+
+	// Set on consumed messages.
+	Topic     string
+	Partition int
+	Offset    int64
+	Time      time.Time
+}
+
 type Producer interface {
-	Publish(ctx context.Context, key, message string) error
+	Publish(ctx context.Context, msg Message) error
 	Close() error
 }
 
 type KafkaProducer struct {
-	writer *kafka.Writer
+	client *kgo.Client
 }
 
-func NewKafkaProducer(broker, topic string) *KafkaProducer {
-	writer := kafka.NewWriter(kafka.WriterConfig{
-		Brokers:      []string{broker},
-		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-	})
-	return &KafkaProducer{writer: writer}
+// NewKafkaProducer returns a producer writing to topic with the settings of
+// Config.producerOptions.
+func NewKafkaProducer(cfg Config, topic string) (*KafkaProducer, error) {
+	opts, err := cfg.producerOptions()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kgo.NewClient(append(opts, kgo.DefaultProduceTopic(topic))...)
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaProducer{client: client}, nil
 }
 
-func (p *KafkaProducer) Publish(ctx context.Context, key, message string) error {
-	err := p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(key),
-		Value: []byte(message),
-	})
+func (p *KafkaProducer) Publish(ctx context.Context, msg Message) error {
+	headers := make([]kgo.RecordHeader, 0, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers = append(headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+	}
+
+	err := p.client.ProduceSync(ctx, &kgo.Record{
+		Key:     []byte(msg.Key),
+		Value:   msg.Value,
+		Headers: headers,
+	}).FirstErr()
 	if err != nil {
 		log.Printf("Failed to publish message to Kafka: %v", err)
 		return err
 	}
-	log.Printf("Successfully published message to Kafka - Key: %s, Message: %s", key, message)
+	log.Printf("Successfully published message to Kafka - Key: %s, Headers: %v, Size: %d bytes", msg.Key, msg.Headers, len(msg.Value))
 	return nil
 }
 
 func (p *KafkaProducer) Close() error {
-	return p.writer.Close()
+	p.client.Close()
+	return nil
 }
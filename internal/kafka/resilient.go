@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy controls how often a failed publish is retried. The wait
+// before retry n is InitialBackoff * 2^(n-1), capped at MaxBackoff, with
+// jitter taking it anywhere between half and all of that.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	AttemptTimeout time.Duration
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// ResilientProducer retries failed publishes, stops calling the broker while
+// its circuit breaker is open and hands messages that could not be published
+// to a dead-letter queue.
+type ResilientProducer struct {
+	Producer    Producer
+	Retry       RetryPolicy
+	Breaker     *CircuitBreaker
+	DeadLetters DeadLetterQueue // Optional; failed messages are dropped when nil
+	Sink        string          // Sink the dead letters are replayed to
+}
+
+func NewResilientProducer(producer Producer, retry RetryPolicy, breaker *CircuitBreaker, deadLetters DeadLetterQueue) *ResilientProducer {
+	return &ResilientProducer{Producer: producer, Retry: retry, Breaker: breaker, DeadLetters: deadLetters}
+}
+
+func (p *ResilientProducer) Publish(ctx context.Context, msg Message) error {
+	err := p.publish(ctx, msg)
+	if err == nil {
+		return nil
+	}
+
+	if p.DeadLetters == nil {
+		return err
+	}
+	if dlqErr := p.DeadLetters.Add(context.WithoutCancel(ctx), p.Sink, msg, err); dlqErr != nil {
+		log.Printf("Failed to dead-letter message with key %s: %v", msg.Key, dlqErr)
+		return fmt.Errorf("%v; dead-lettering failed: %v", err, dlqErr)
+	}
+	log.Printf("Dead-lettered message with key %s: %v", msg.Key, err)
+	return fmt.Errorf("message dead-lettered: %w", err)
+}
+
+func (p *ResilientProducer) publish(ctx context.Context, msg Message) error {
+	attempts := p.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(p.Retry.backoff(attempt - 1)):
+			}
+		}
+
+		if p.Breaker != nil && !p.Breaker.Allow() {
+			return ErrCircuitOpen
+		}
+
+		err = p.attempt(ctx, msg)
+		if err == nil {
+			if p.Breaker != nil {
+				p.Breaker.Success()
+			}
+			return nil
+		}
+		if p.Breaker != nil {
+			p.Breaker.Failure()
+		}
+		log.Printf("Publish attempt %d/%d for key %s failed: %v", attempt, attempts, msg.Key, err)
+	}
+	return err
+}
+
+func (p *ResilientProducer) attempt(ctx context.Context, msg Message) error {
+	if p.Retry.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Retry.AttemptTimeout)
+		defer cancel()
+	}
+	return p.Producer.Publish(ctx, msg)
+}
+
+func (p *ResilientProducer) Close() error {
+	return p.Producer.Close()
+}
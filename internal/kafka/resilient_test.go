@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyProducer struct {
+	failures  int
+	published []Message
+	attempts  int
+}
+
+func (p *flakyProducer) Publish(ctx context.Context, msg Message) error {
+	p.attempts++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *flakyProducer) Close() error {
+	return nil
+}
+
+func TestResilientProducerRetries(t *testing.T) {
+	flaky := &flakyProducer{failures: 2}
+	producer := NewResilientProducer(flaky, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}, nil, nil)
+
+	err := producer.Publish(context.Background(), Message{Key: "1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, flaky.attempts)
+	assert.Len(t, flaky.published, 1)
+}
+
+func TestResilientProducerDeadLettersAfterRetries(t *testing.T) {
+	flaky := &flakyProducer{failures: 10}
+	deadLetters := NewFileDeadLetters(filepath.Join(t.TempDir(), "dead_letters.jsonl"))
+	producer := NewResilientProducer(flaky, RetryPolicy{MaxAttempts: 2}, nil, deadLetters)
+	producer.Sink = "kafka"
+
+	err := producer.Publish(context.Background(), Message{Key: "1", Value: []byte{0, 1}, Headers: map[string]string{"event-type": "company.CompanyCreated"}})
+	assert.Error(t, err)
+	assert.Equal(t, 2, flaky.attempts)
+
+	// Replaying while the broker is still down keeps the dead letter.
+	replayed, err := deadLetters.Replay(context.Background(), map[string]Producer{"kafka": &flakyProducer{failures: 1}})
+	assert.Error(t, err)
+	assert.Equal(t, 0, replayed)
+
+	recovered := &flakyProducer{}
+	replayed, err = deadLetters.Replay(context.Background(), map[string]Producer{"kafka": recovered})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, []byte{0, 1}, recovered.published[0].Value)
+	assert.Equal(t, "company.CompanyCreated", recovered.published[0].Headers["event-type"])
+
+	replayed, err = deadLetters.Replay(context.Background(), map[string]Producer{"kafka": recovered})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replayed)
+}
+
+func TestDeadLettersReplayOnlyToTheFailedSink(t *testing.T) {
+	deadLetters := NewFileDeadLetters(filepath.Join(t.TempDir(), "dead_letters.jsonl"))
+	healthy := NewResilientProducer(&flakyProducer{}, RetryPolicy{}, nil, deadLetters)
+	healthy.Sink = "file"
+	failing := NewResilientProducer(&flakyProducer{failures: 1}, RetryPolicy{}, nil, deadLetters)
+	failing.Sink = "nats"
+
+	msg := Message{Key: "1"}
+	assert.NoError(t, healthy.Publish(context.Background(), msg))
+	assert.Error(t, failing.Publish(context.Background(), msg))
+
+	// A sink that is no longer configured keeps its dead letters.
+	replayed, err := deadLetters.Replay(context.Background(), map[string]Producer{"file": &flakyProducer{}})
+	assert.ErrorContains(t, err, `sink "nats" is not configured`)
+	assert.Equal(t, 0, replayed)
+
+	file, nats := &flakyProducer{}, &flakyProducer{}
+	replayed, err = deadLetters.Replay(context.Background(), map[string]Producer{"file": file, "nats": nats})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Empty(t, file.published)
+	assert.Equal(t, []Message{msg}, nats.published)
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	flaky := &flakyProducer{failures: 10}
+	producer := NewResilientProducer(flaky, RetryPolicy{MaxAttempts: 5}, breaker, nil)
+
+	err := producer.Publish(context.Background(), Message{Key: "1"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, flaky.attempts)
+
+	err = producer.Publish(context.Background(), Message{Key: "1"})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, flaky.attempts)
+
+	// After the cooldown a single probe goes through and closes the breaker.
+	now = now.Add(time.Minute)
+	flaky.failures = 0
+	assert.NoError(t, producer.Publish(context.Background(), Message{Key: "1"}))
+	assert.True(t, breaker.Allow())
+}
+
+func TestBackoffIsCappedWithJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(3)
+		assert.GreaterOrEqual(t, backoff, 200*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 400*time.Millisecond)
+		assert.LessOrEqual(t, policy.backoff(10), time.Second)
+	}
+}
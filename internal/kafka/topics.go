@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// EnsureCompactedTopic creates topic with log compaction, so that only the
+// latest message per key is kept. An existing topic is left as it is.
+func EnsureCompactedTopic(ctx context.Context, cfg Config, topic string, partitions, replicationFactor int) error {
+	if len(cfg.Brokers) == 0 {
+		return errors.New("no Kafka brokers configured")
+	}
+	dialer := cfg.dialer()
+
+	conn, err := dialer.DialContext(ctx, "tcp", cfg.Brokers[0])
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	controller, err := conn.Controller()
+	if err != nil {
+		return err
+	}
+	controllerConn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+
+	err = controllerConn.CreateTopics(kafka.TopicConfig{
+		Topic:             topic,
+		NumPartitions:     partitions,
+		ReplicationFactor: replicationFactor,
+		ConfigEntries: []kafka.ConfigEntry{
+			{ConfigName: "cleanup.policy", ConfigValue: "compact"},
+		},
+	})
+	if errors.Is(err, kafka.TopicAlreadyExists) {
+		return nil
+	}
+	return err
+}
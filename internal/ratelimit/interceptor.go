@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"company-service/internal/auth"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"path"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const loginMethod = "/company.CompanyService/Login"
+
+type Interceptor struct {
+	Limiter     Limiter
+	Lockouts    LockoutStore
+	DefaultRule Rule
+	MethodRules map[string]Rule
+	Lockout     LockoutPolicy
+}
+
+func NewInterceptor(limiter Limiter, lockouts LockoutStore, defaultRule Rule, methodRules map[string]Rule, policy LockoutPolicy) *Interceptor {
+	return &Interceptor{
+		Limiter:     limiter,
+		Lockouts:    lockouts,
+		DefaultRule: defaultRule,
+		MethodRules: methodRules,
+		Lockout:     policy,
+	}
+}
+
+// UnaryInterceptor applies a token bucket per method and peer IP and, for
+// Login, progressively locks out users and IPs that keep failing. It runs
+// before authentication, so calls without a valid token are limited too.
+func (i *Interceptor) UnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+
+	if err := i.allow(ctx, info.FullMethod, "ip:"+peerIP(ctx)); err != nil {
+		return nil, err
+	}
+
+	if info.FullMethod != loginMethod || i.Lockouts == nil {
+		return handler(ctx, req)
+	}
+	return i.login(ctx, req, handler)
+}
+
+func (i *Interceptor) StreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+
+	if err := i.allow(stream.Context(), info.FullMethod, "ip:"+peerIP(stream.Context())); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// UserUnaryInterceptor applies a token bucket per method and authenticated
+// user, so that a user cannot get around the limits by spreading calls over
+// several IPs. It runs after authentication; calls without a user, such as
+// Login, are only limited per IP.
+func (i *Interceptor) UserUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+
+	if userID, ok := auth.UserIDFromContext(ctx); ok {
+		if err := i.allow(ctx, info.FullMethod, fmt.Sprintf("user:%d", userID)); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+func (i *Interceptor) UserStreamInterceptor(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+
+	if userID, ok := auth.UserIDFromContext(stream.Context()); ok {
+		if err := i.allow(stream.Context(), info.FullMethod, fmt.Sprintf("user:%d", userID)); err != nil {
+			return err
+		}
+	}
+	return handler(srv, stream)
+}
+
+func (i *Interceptor) allow(ctx context.Context, fullMethod, caller string) error {
+	method := path.Base(fullMethod)
+	rule, ok := i.MethodRules[method]
+	if !ok {
+		rule = i.DefaultRule
+	}
+
+	key := method + ":" + caller
+	allowed, wait, err := i.Limiter.Allow(ctx, key, rule)
+	if err != nil {
+		log.Printf("Rate limiter unavailable, allowing %s: %v", key, err)
+		return nil
+	}
+	if !allowed {
+		return exhausted(ctx, wait, fmt.Sprintf("rate limit exceeded for %s", method))
+	}
+	return nil
+}
+
+func (i *Interceptor) login(ctx context.Context, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	keys := []string{"login:ip:" + peerIP(ctx)}
+	if r, ok := req.(interface{ GetUserId() int64 }); ok {
+		keys = append(keys, fmt.Sprintf("login:user:%d", r.GetUserId()))
+	}
+
+	for _, key := range keys {
+		remaining, err := i.Lockouts.LockedFor(ctx, key)
+		if err != nil {
+			log.Printf("Lockout store unavailable, allowing %s: %v", key, err)
+			continue
+		}
+		if remaining > 0 {
+			return nil, exhausted(ctx, remaining, "too many failed login attempts")
+		}
+	}
+
+	// Only rejected credentials count as failures; an outage failing every
+	// login must not lock out real users.
+	resp, err := handler(ctx, req)
+	code := status.Code(err)
+	if err != nil && code != codes.Unauthenticated && code != codes.PermissionDenied {
+		return resp, err
+	}
+	for _, key := range keys {
+		if err != nil {
+			if duration, lockErr := i.Lockouts.RecordFailure(ctx, key, i.Lockout); lockErr != nil {
+				log.Printf("Failed to record login failure for %s: %v", key, lockErr)
+			} else if duration > 0 {
+				log.Printf("Locked out %s for %s after repeated login failures", key, duration)
+			}
+		} else if resetErr := i.Lockouts.Reset(ctx, key); resetErr != nil {
+			log.Printf("Failed to reset login lockout for %s: %v", key, resetErr)
+		}
+	}
+	return resp, err
+}
+
+func exhausted(ctx context.Context, wait time.Duration, msg string) error {
+	retryAfter := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+	return status.Errorf(codes.ResourceExhausted, "%s, retry after %ss", msg, retryAfter)
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is a token bucket refilled at Rate tokens per second up to Burst tokens.
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+type Limiter interface {
+	// Allow takes one token from the bucket identified by key. When the bucket
+	// is empty it returns false and how long until a token becomes available.
+	Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error)
+}
+
+type LockoutStore interface {
+	// LockedFor returns the remaining lockout for key, or zero if it is not locked.
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// RecordFailure counts a failed attempt and returns the lockout it triggered, if any.
+	RecordFailure(ctx context.Context, key string, policy LockoutPolicy) (time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy locks a key once MaxFailures consecutive failures are reached,
+// doubling the lockout from Base for every further failure up to Max.
+type LockoutPolicy struct {
+	MaxFailures int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+func (p LockoutPolicy) lockoutFor(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+	lockout := p.Base
+	for i := p.MaxFailures; i < failures; i++ {
+		lockout *= 2
+		if p.Max > 0 && lockout >= p.Max {
+			return p.Max
+		}
+	}
+	if p.Max > 0 && lockout > p.Max {
+		return p.Max
+	}
+	return lockout
+}
+
+func take(tokens float64, last, now time.Time, rule Rule) (float64, bool, time.Duration) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(rule.Burst), tokens+elapsed*rule.Rate)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	if rule.Rate <= 0 {
+		return tokens, false, time.Minute
+	}
+	wait := time.Duration((1 - tokens) / rule.Rate * float64(time.Second))
+	return tokens, false, wait
+}
+
+// ParseRules parses per-method limits in the form "Login=1:5,CreateCompany=10:20",
+// where each value is the refill rate per second and the burst size.
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		method, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected Method=rate:burst", entry)
+		}
+		rateStr, burstStr, ok := strings.Cut(limit, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q: expected Method=rate:burst", entry)
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate in %q: %v", entry, err)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid burst in %q: %v", entry, err)
+		}
+		rules[strings.TrimSpace(method)] = Rule{Rate: rate, Burst: burst}
+	}
+	return rules, nil
+}
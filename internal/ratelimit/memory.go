@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const maxMemoryKeys = 100000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	rule   Rule
+}
+
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxMemoryKeys {
+			l.prune(now)
+		}
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.rule = rule
+
+	tokens, allowed, wait := take(b.tokens, b.last, now, rule)
+	b.tokens, b.last = tokens, now
+	return allowed, wait, nil
+}
+
+// prune drops buckets that have refilled completely, since they are
+// indistinguishable from a fresh bucket.
+func (l *MemoryLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rule.Rate >= float64(b.rule.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+type lockout struct {
+	failures    int
+	lockedUntil time.Time
+	forgetAt    time.Time
+}
+
+type MemoryLockoutStore struct {
+	mu       sync.Mutex
+	lockouts map[string]*lockout
+	now      func() time.Time
+}
+
+func NewMemoryLockoutStore() *MemoryLockoutStore {
+	return &MemoryLockoutStore{lockouts: make(map[string]*lockout), now: time.Now}
+}
+
+func (s *MemoryLockoutStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.lockouts[key]; ok {
+		if remaining := l.lockedUntil.Sub(s.now()); remaining > 0 {
+			return remaining, nil
+		}
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failure for key. Failures are forgotten once key
+// has not failed and was not locked out for policy.Max, so that keys an
+// attacker makes up do not accumulate.
+func (s *MemoryLockoutStore) RecordFailure(ctx context.Context, key string, policy LockoutPolicy) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	l, ok := s.lockouts[key]
+	if !ok || !now.Before(l.forgetAt) {
+		if !ok && len(s.lockouts) >= maxMemoryKeys {
+			s.prune(now)
+		}
+		l = &lockout{}
+		s.lockouts[key] = l
+	}
+	l.failures++
+	duration := policy.lockoutFor(l.failures)
+	if duration > 0 {
+		l.lockedUntil = now.Add(duration)
+	}
+	l.forgetAt = now.Add(policy.Max)
+	if l.lockedUntil.After(now) {
+		l.forgetAt = l.lockedUntil.Add(policy.Max)
+	}
+	return duration, nil
+}
+
+// prune drops the lockouts whose failures are forgotten.
+func (s *MemoryLockoutStore) prune(now time.Time) {
+	for key, l := range s.lockouts {
+		if !now.Before(l.forgetAt) {
+			delete(s.lockouts, key)
+		}
+	}
+}
+
+func (s *MemoryLockoutStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.lockouts, key)
+	return nil
+}
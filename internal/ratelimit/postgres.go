@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"company-service/internal/db"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// PostgresLimiter keeps token buckets in the rate_limit_buckets table so that
+// every replica of the service shares the same limits.
+type PostgresLimiter struct {
+	DB         *sql.DB
+	Transactor *db.Transactor
+}
+
+func NewPostgresLimiter(database *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{DB: database, Transactor: db.NewTransactor(database)}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+	err := l.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, l.DB)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (key) DO NOTHING
+		`, key, float64(rule.Burst))
+		if err != nil {
+			return fmt.Errorf("could not create rate limit bucket: %w", err)
+		}
+
+		var tokens float64
+		var last, now time.Time
+		err = tx.QueryRowContext(ctx,
+			"SELECT tokens, updated_at, NOW() FROM rate_limit_buckets WHERE key = $1 FOR UPDATE", key,
+		).Scan(&tokens, &last, &now)
+		if err != nil {
+			return fmt.Errorf("could not read rate limit bucket: %w", err)
+		}
+
+		tokens, allowed, wait = take(tokens, last, now, rule)
+		_, err = tx.ExecContext(ctx,
+			"UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2 WHERE key = $3", tokens, now, key)
+		if err != nil {
+			return fmt.Errorf("could not update rate limit bucket: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed, wait, nil
+}
+
+type PostgresLockoutStore struct {
+	DB         *sql.DB
+	Transactor *db.Transactor
+}
+
+func NewPostgresLockoutStore(database *sql.DB) *PostgresLockoutStore {
+	return &PostgresLockoutStore{DB: database, Transactor: db.NewTransactor(database)}
+}
+
+func (s *PostgresLockoutStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	var remaining float64
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT EXTRACT(EPOCH FROM (locked_until - NOW()))
+		FROM login_lockouts
+		WHERE key = $1 AND locked_until > NOW()
+	`, key).Scan(&remaining)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not read login lockout: %v", err)
+	}
+	return time.Duration(remaining * float64(time.Second)), nil
+}
+
+// RecordFailure counts a failure for key. Like in the memory store, failures
+// are forgotten once key has not failed and was not locked out for
+// policy.Max; RunCleanup deletes them.
+func (s *PostgresLockoutStore) RecordFailure(ctx context.Context, key string, policy LockoutPolicy) (time.Duration, error) {
+	var duration time.Duration
+	err := s.Transactor.WithTx(ctx, nil, func(ctx context.Context) error {
+		tx := db.QuerierFromContext(ctx, s.DB)
+		var failures int
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO login_lockouts (key, failures)
+			VALUES ($1, 1)
+			ON CONFLICT (key) DO UPDATE
+			SET failures = CASE WHEN login_lockouts.forget_at <= NOW() THEN 1 ELSE login_lockouts.failures + 1 END
+			RETURNING failures
+		`, key).Scan(&failures)
+		if err != nil {
+			return fmt.Errorf("could not record login failure: %w", err)
+		}
+
+		duration = policy.lockoutFor(failures)
+		_, err = tx.ExecContext(ctx, `
+			UPDATE login_lockouts
+			SET locked_until = CASE WHEN $1::float8 > 0 THEN NOW() + make_interval(secs => $1) ELSE locked_until END,
+			    forget_at = GREATEST(NOW(), CASE WHEN $1::float8 > 0 THEN NOW() + make_interval(secs => $1) ELSE locked_until END)
+			        + make_interval(secs => $2)
+			WHERE key = $3
+		`, duration.Seconds(), policy.Max.Seconds(), key)
+		if err != nil {
+			return fmt.Errorf("could not lock out %s: %w", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return duration, nil
+}
+
+func (s *PostgresLockoutStore) Reset(ctx context.Context, key string) error {
+	if _, err := s.DB.ExecContext(ctx, "DELETE FROM login_lockouts WHERE key = $1", key); err != nil {
+		return fmt.Errorf("could not reset login lockout: %v", err)
+	}
+	return nil
+}
+
+// RunCleanup deletes forgotten failures every interval until ctx is done.
+func (s *PostgresLockoutStore) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DB.ExecContext(ctx, "DELETE FROM login_lockouts WHERE forget_at <= NOW()"); err != nil {
+				log.Printf("Failed to remove forgotten login failures: %v", err)
+			}
+		}
+	}
+}
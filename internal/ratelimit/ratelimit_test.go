@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"company-service/internal/auth"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type loginRequest struct{ userID int64 }
+
+func (r *loginRequest) GetUserId() int64 { return r.userID }
+
+func TestMemoryLimiterRefillsTokens(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	rule := Rule{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(context.Background(), "key", rule)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, wait, _ := limiter.Allow(context.Background(), "key", rule)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, wait)
+
+	now = now.Add(time.Second)
+	allowed, _, _ = limiter.Allow(context.Background(), "key", rule)
+	assert.True(t, allowed)
+}
+
+func TestMemoryLockoutStoreForgetsQuietKeys(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := NewMemoryLockoutStore()
+	store.now = func() time.Time { return now }
+	policy := LockoutPolicy{MaxFailures: 2, Base: time.Minute, Max: time.Hour}
+
+	for _, key := range []string{"a", "b", "b"} {
+		_, err := store.RecordFailure(context.Background(), key, policy)
+		assert.NoError(t, err)
+	}
+	remaining, _ := store.LockedFor(context.Background(), "b")
+	assert.Equal(t, time.Minute, remaining)
+
+	now = now.Add(time.Hour)
+	store.prune(now)
+	assert.Len(t, store.lockouts, 1)
+
+	now = now.Add(time.Minute)
+	duration, _ := store.RecordFailure(context.Background(), "b", policy)
+	assert.Zero(t, duration)
+	store.prune(now)
+	assert.Len(t, store.lockouts, 1)
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("Login=0.5:5, CreateCompany=10:20")
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Rate: 0.5, Burst: 5}, rules["Login"])
+	assert.Equal(t, Rule{Rate: 10, Burst: 20}, rules["CreateCompany"])
+
+	_, err = ParseRules("Login=5")
+	assert.Error(t, err)
+}
+
+func TestLockoutPolicyIsProgressive(t *testing.T) {
+	policy := LockoutPolicy{MaxFailures: 3, Base: time.Second, Max: 5 * time.Second}
+
+	assert.Equal(t, time.Duration(0), policy.lockoutFor(2))
+	assert.Equal(t, time.Second, policy.lockoutFor(3))
+	assert.Equal(t, 2*time.Second, policy.lockoutFor(4))
+	assert.Equal(t, 4*time.Second, policy.lockoutFor(5))
+	assert.Equal(t, 5*time.Second, policy.lockoutFor(6))
+}
+
+func TestInterceptorRateLimitsMethod(t *testing.T) {
+	interceptor := NewInterceptor(NewMemoryLimiter(), nil, Rule{Rate: 1, Burst: 1}, nil, LockoutPolicy{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/company.CompanyService/GetCompany"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	_, err := interceptor.UnaryInterceptor(context.Background(), nil, info, handler)
+	assert.NoError(t, err)
+
+	_, err = interceptor.UnaryInterceptor(context.Background(), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestInterceptorLocksOutFailedLogins(t *testing.T) {
+	interceptor := NewInterceptor(
+		NewMemoryLimiter(),
+		NewMemoryLockoutStore(),
+		Rule{Rate: 100, Burst: 100},
+		nil,
+		LockoutPolicy{MaxFailures: 2, Base: time.Minute, Max: time.Hour},
+	)
+	info := &grpc.UnaryServerInfo{FullMethod: loginMethod}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := interceptor.UnaryInterceptor(context.Background(), &loginRequest{userID: 1}, info, handler)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	_, err := interceptor.UnaryInterceptor(context.Background(), &loginRequest{userID: 1}, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 2, calls)
+}
+
+func TestInterceptorDoesNotLockOutOnServerErrors(t *testing.T) {
+	interceptor := NewInterceptor(
+		NewMemoryLimiter(),
+		NewMemoryLockoutStore(),
+		Rule{Rate: 100, Burst: 100},
+		nil,
+		LockoutPolicy{MaxFailures: 1, Base: time.Minute, Max: time.Hour},
+	)
+	info := &grpc.UnaryServerInfo{FullMethod: loginMethod}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("database unavailable")
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := interceptor.UnaryInterceptor(context.Background(), &loginRequest{userID: 1}, info, handler)
+		assert.EqualError(t, err, "database unavailable")
+	}
+}
+
+func TestUserInterceptorLimitsPerUser(t *testing.T) {
+	interceptor := NewInterceptor(NewMemoryLimiter(), nil, Rule{Rate: 1, Burst: 1}, nil, LockoutPolicy{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/company.CompanyService/GetCompany"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	user := auth.ContextWithUserID(context.Background(), 1)
+
+	_, err := interceptor.UserUnaryInterceptor(user, nil, info, handler)
+	assert.NoError(t, err)
+
+	_, err = interceptor.UserUnaryInterceptor(user, nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = interceptor.UserUnaryInterceptor(auth.ContextWithUserID(context.Background(), 2), nil, info, handler)
+	assert.NoError(t, err)
+
+	_, err = interceptor.UserUnaryInterceptor(context.Background(), nil, info, handler)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+package sinks
+
+import (
+	"company-service/internal/events"
+	"company-service/internal/kafka"
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HeaderKey carries the message key on sinks that have no key of their own.
+const HeaderKey = "key"
+
+// NATSSink publishes to JetStream on the subject <subject>.<key>, so
+// consumers can subscribe to a single company. The event id, from the
+// event-id header or the CloudEvents envelope, is used as the JetStream
+// message id, so a retried publish is stored once.
+type NATSSink struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+// NewNATSSink connects to url and, when stream is set, creates the stream
+// for <subject>.> unless it already exists.
+func NewNATSSink(ctx context.Context, url, stream, subject string) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("company-service"))
+	if err != nil {
+		return nil, err
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if stream != "" {
+		_, err := js.Stream(ctx, stream)
+		if errors.Is(err, jetstream.ErrStreamNotFound) {
+			_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: stream, Subjects: []string{subject + ".>"}})
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return &NATSSink{conn: conn, js: js, subject: subject}, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, msg kafka.Message) error {
+	natsMsg := nats.NewMsg(s.subject + "." + msg.Key)
+	natsMsg.Data = msg.Value
+	for key, value := range msg.Headers {
+		natsMsg.Header.Set(key, value)
+	}
+	natsMsg.Header.Set(HeaderKey, msg.Key)
+
+	var opts []jetstream.PublishOpt
+	if id := events.EventID(msg.Value, msg.Headers); id != "" {
+		opts = append(opts, jetstream.WithMsgID(id))
+	}
+	_, err := s.js.PublishMsg(ctx, natsMsg, opts...)
+	return err
+}
+
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
@@ -0,0 +1,108 @@
+package sinks
+
+import (
+	"company-service/internal/kafka"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FanOut publishes every message to all of its sinks in turn. A message that
+// fails on one sink is still published to the others, and the failures are
+// returned together.
+type FanOut struct {
+	Sinks []kafka.Producer
+}
+
+func NewFanOut(sinks ...kafka.Producer) *FanOut {
+	return &FanOut{Sinks: sinks}
+}
+
+func (f *FanOut) Publish(ctx context.Context, msg kafka.Message) error {
+	var errs []error
+	for _, sink := range f.Sinks {
+		if err := sink.Publish(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f *FanOut) Close() error {
+	var errs []error
+	for _, sink := range f.Sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Noop drops every message.
+type Noop struct{}
+
+func (Noop) Publish(ctx context.Context, msg kafka.Message) error {
+	return nil
+}
+
+func (Noop) Close() error {
+	return nil
+}
+
+// WriterSink writes each message as a line of JSON. Values that are JSON
+// themselves are embedded as they are, others are base64 encoded.
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{w: os.Stdout}
+}
+
+// NewFileSink appends messages to the file at path, creating it if needed.
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterSink{w: file, closer: file}, nil
+}
+
+type record struct {
+	Key         string            `json:"key"`
+	Value       json.RawMessage   `json:"value,omitempty"`
+	ValueBase64 []byte            `json:"value_base64,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	PublishedAt time.Time         `json:"published_at"`
+}
+
+func (s *WriterSink) Publish(ctx context.Context, msg kafka.Message) error {
+	rec := record{Key: msg.Key, Headers: msg.Headers, PublishedAt: time.Now().UTC()}
+	if json.Valid(msg.Value) {
+		rec.Value = msg.Value
+	} else {
+		rec.ValueBase64 = msg.Value
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *WriterSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
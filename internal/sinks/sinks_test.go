@@ -0,0 +1,58 @@
+package sinks
+
+import (
+	"company-service/internal/kafka"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSink struct{}
+
+func (failingSink) Publish(ctx context.Context, msg kafka.Message) error {
+	return errors.New("broker unavailable")
+}
+
+func (failingSink) Close() error {
+	return nil
+}
+
+func TestFanOutPublishesToEverySink(t *testing.T) {
+	first, second := kafka.NewFake(), kafka.NewFake()
+	fanOut := NewFanOut(first, failingSink{}, second)
+
+	err := fanOut.Publish(context.Background(), kafka.Message{Key: "1", Value: []byte("event")})
+
+	assert.EqualError(t, err, "broker unavailable")
+	assert.Len(t, first.Messages(), 1)
+	assert.Len(t, second.Messages(), 1)
+}
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	assert.NoError(t, sink.Publish(ctx, kafka.Message{Key: "1", Value: []byte(`{"name":"Test Co"}`), Headers: map[string]string{"event-type": "company.CompanyCreated"}}))
+	assert.NoError(t, sink.Publish(ctx, kafka.Message{Key: "2", Value: []byte{0x0a, 0x01}}))
+	assert.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+
+	var first, second record
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.JSONEq(t, `{"name":"Test Co"}`, string(first.Value))
+	assert.Equal(t, "company.CompanyCreated", first.Headers["event-type"])
+	assert.Equal(t, []byte{0x0a, 0x01}, second.ValueBase64)
+}
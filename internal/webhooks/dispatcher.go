@@ -0,0 +1,171 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Dispatcher sends the queued deliveries. A delivery that fails is retried
+// with exponential backoff and jitter until MaxAttempts, and a subscription
+// is disabled after DisableAfter of its deliveries have failed in a row.
+// Connections to addresses Policy rejects fail like unreachable endpoints.
+type Dispatcher struct {
+	Store          *Store
+	Client         *http.Client
+	Policy         Policy
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	DisableAfter   int
+	BatchSize      int
+}
+
+func NewDispatcher(store *Store, timeout time.Duration) *Dispatcher {
+	d := &Dispatcher{
+		Store:          store,
+		MaxAttempts:    8,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Hour,
+		DisableAfter:   5,
+		BatchSize:      20,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			return d.Policy.dialControl(network, address, c)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the dialer check the proxy instead of the endpoint.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	d.Client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Redirects are not followed; a 3xx counts as a failed attempt.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return d
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			sent, err := d.DispatchDue(ctx)
+			if err != nil {
+				log.Printf("Failed to dispatch webhooks: %v", err)
+			}
+			if err != nil || sent < d.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries concurrently and returns how
+// many it sent.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.Store.Claim(ctx, d.BatchSize, d.Client.Timeout+30*time.Second)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *Delivery) {
+	attempt := d.send(ctx, delivery)
+
+	var retryAt time.Time
+	if attempt.Error != "" {
+		if n := delivery.Attempts + 1; n < d.MaxAttempts {
+			retryAt = attempt.AttemptedAt.Add(d.backoff(n))
+		}
+		log.Printf("Webhook delivery %d to %s failed: %s", delivery.ID, delivery.URL, attempt.Error)
+	}
+
+	if err := d.Store.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt, retryAt, d.DisableAfter); err != nil {
+		log.Printf("Failed to record webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *Delivery) (attempt Attempt) {
+	attempt.AttemptedAt = time.Now()
+	defer func() {
+		attempt.Duration = time.Since(attempt.AttemptedAt)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "company-service-webhooks")
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(attempt.AttemptedAt.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, attempt.AttemptedAt, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	// The body is drained for connection reuse but never stored, since it
+	// is shown to whoever lists the deliveries.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// backoff returns the wait before the retry following attempt n:
+// InitialBackoff * 2^(n-1), capped at MaxBackoff, jittered between half and
+// all of that.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	backoff := d.InitialBackoff << (n - 1)
+	if backoff <= 0 || backoff > d.MaxBackoff {
+		backoff = d.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
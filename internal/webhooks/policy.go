@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// Policy limits where webhooks may be sent. By default endpoints must use
+// https and resolve to public addresses, so that subscribers cannot make the
+// service call into its own network.
+type Policy struct {
+	AllowHTTP            bool // Accept plain http endpoints
+	AllowPrivateNetworks bool // Accept loopback, private and link-local addresses
+}
+
+var errUnsafeURL = errors.New("url must be an absolute https URL")
+
+// carrierGradeNAT is the shared address space of RFC 6598, private in
+// practice but not covered by netip.Addr.IsPrivate.
+var carrierGradeNAT = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckURL validates an endpoint when it is registered. Host names are only
+// resolved when sending, where CheckAddress has the final word.
+func (p Policy) CheckURL(rawURL string) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || endpoint.Host == "" || endpoint.User != nil {
+		return errUnsafeURL
+	}
+	switch endpoint.Scheme {
+	case "https":
+	case "http":
+		if !p.AllowHTTP {
+			return errUnsafeURL
+		}
+	default:
+		return errUnsafeURL
+	}
+	if addr, err := netip.ParseAddr(endpoint.Hostname()); err == nil {
+		return p.CheckAddress(addr)
+	}
+	if endpoint.Hostname() == "localhost" && !p.AllowPrivateNetworks {
+		return fmt.Errorf("webhooks to localhost are not allowed")
+	}
+	return nil
+}
+
+// CheckAddress rejects the addresses of the service's own network unless
+// AllowPrivateNetworks is set.
+func (p Policy) CheckAddress(addr netip.Addr) error {
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || carrierGradeNAT.Contains(addr) {
+		return fmt.Errorf("webhooks to %s are not allowed", addr)
+	}
+	return nil
+}
+
+// dialControl applies CheckAddress to every connection after name
+// resolution, so a host name cannot point the dispatcher at a blocked
+// address.
+func (p Policy) dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	return p.CheckAddress(addr)
+}
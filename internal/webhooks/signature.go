@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every webhook request.
+const (
+	HeaderDelivery  = "Webhook-Delivery"
+	HeaderEventID   = "Webhook-Event-Id"
+	HeaderEventType = "Webhook-Event-Type"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// Sign returns the signature of a request body sent at timestamp: "v1="
+// followed by the hex HMAC-SHA256 of "<unix seconds>.<body>" keyed with the
+// subscription secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp.Unix())
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a request received from this service. Requests whose
+// timestamp is further than tolerance from now are rejected so that captured
+// requests cannot be replayed.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook timestamp %q", timestamp)
+	}
+	sentAt := time.Unix(seconds, 0)
+	if age := time.Since(sentAt); age > tolerance || age < -tolerance {
+		return errors.New("webhook timestamp is outside the tolerance")
+	}
+	if !strings.HasPrefix(signature, "v1=") || !hmac.Equal([]byte(Sign(secret, sentAt, body)), []byte(signature)) {
+		return errors.New("webhook signature does not match")
+	}
+	return nil
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"company-service/internal/events"
+	"company-service/internal/kafka"
+	pb "company-service/proto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Payload is the JSON body posted to webhook endpoints. Event is the event
+// message from proto/company_events.proto in the proto3 JSON mapping.
+type Payload struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	CompanyID     int64           `json:"company_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	SchemaVersion string          `json:"schema_version"`
+	Event         json.RawMessage `json:"event"`
+}
+
+// Sink is an event sink that queues a webhook delivery of every published
+// event for the subscriptions that want it. The dispatcher sends them.
+type Sink struct {
+	Store *Store
+}
+
+func NewSink(store *Store) *Sink {
+	return &Sink{Store: store}
+}
+
+type companyEvent interface {
+	proto.Message
+	GetCompany() *pb.Company
+	GetOccurredAt() *timestamppb.Timestamp
+}
+
+func (s *Sink) Publish(ctx context.Context, msg kafka.Message) error {
+	event, eventID, err := decode(msg)
+	if err != nil {
+		return err
+	}
+	companyEvent, ok := event.(companyEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event message %T", event)
+	}
+
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(event)
+	if err != nil {
+		return err
+	}
+	eventType := events.EventType(event)
+	payload, err := json.Marshal(Payload{
+		ID:            eventID,
+		Type:          eventType,
+		CompanyID:     companyEvent.GetCompany().GetId(),
+		OccurredAt:    companyEvent.GetOccurredAt().AsTime(),
+		SchemaVersion: events.SchemaVersion,
+		Event:         data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Store.Enqueue(ctx, eventID, eventType, companyEvent.GetCompany().GetId(), payload)
+	return err
+}
+
+// decode reads an event published with or without a CloudEvents envelope.
+func decode(msg kafka.Message) (proto.Message, string, error) {
+	if cloudEvent, err := events.ParseCloudEvent(msg.Value, msg.Headers); err == nil {
+		event, err := cloudEvent.Message()
+		return event, cloudEvent.ID, err
+	}
+	event, err := events.Decode(msg.Value, msg.Headers)
+	return event, msg.Headers[events.HeaderEventID], err
+}
+
+func (s *Sink) Close() error {
+	return nil
+}
@@ -0,0 +1,333 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "PENDING"
+	StatusSucceeded = "SUCCEEDED"
+	StatusFailed    = "FAILED"
+)
+
+type Subscription struct {
+	ID                  int64
+	URL                 string
+	EventTypes          []string
+	Secret              string
+	Active              bool
+	ConsecutiveFailures int
+	DisabledAt          sql.NullTime
+	DisabledReason      string
+	CreatedAt           time.Time
+}
+
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        string
+	EventType      string
+	CompanyID      int64
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+	AttemptLog     []Attempt
+
+	// Set on deliveries claimed for sending.
+	URL    string
+	Secret string
+}
+
+type Attempt struct {
+	AttemptedAt time.Time
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+}
+
+// DeliveryFilter selects deliveries, newest first, with ids below BeforeID
+// when it is set.
+type DeliveryFilter struct {
+	SubscriptionID int64
+	Status         string
+	BeforeID       int64
+	Limit          int
+}
+
+// Store keeps webhook subscriptions and the deliveries queued for them.
+type Store struct {
+	DB *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{DB: db}
+}
+
+const subscriptionColumns = "id, url, event_types, secret, active, consecutive_failures, disabled_at, disabled_reason, created_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	var sub Subscription
+	err := row.Scan(&sub.ID, &sub.URL, pq.Array(&sub.EventTypes), &sub.Secret, &sub.Active,
+		&sub.ConsecutiveFailures, &sub.DisabledAt, &sub.DisabledReason, &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *Store) Create(ctx context.Context, url string, eventTypes []string, secret string) (*Subscription, error) {
+	query := "INSERT INTO webhook_subscriptions (url, event_types, secret) VALUES ($1, $2, $3) RETURNING " + subscriptionColumns
+	return scanSubscription(s.DB.QueryRowContext(ctx, query, url, pq.Array(eventTypes), secret))
+}
+
+// Update replaces the URL and event types of a subscription. Activating it
+// clears its failures; deactivating it disables it with reason.
+func (s *Store) Update(ctx context.Context, id int64, url string, eventTypes []string, active bool, reason string) (*Subscription, error) {
+	query := `UPDATE webhook_subscriptions
+		SET url = $2, event_types = $3, active = $4,
+		    consecutive_failures = CASE WHEN $4 THEN 0 ELSE consecutive_failures END,
+		    disabled_at = CASE WHEN $4 THEN NULL WHEN active THEN NOW() ELSE disabled_at END,
+		    disabled_reason = CASE WHEN $4 THEN '' WHEN active THEN $5 ELSE disabled_reason END
+		WHERE id = $1
+		RETURNING ` + subscriptionColumns
+	return scanSubscription(s.DB.QueryRowContext(ctx, query, id, url, pq.Array(eventTypes), active, reason))
+}
+
+func (s *Store) Delete(ctx context.Context, id int64) (bool, error) {
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+func (s *Store) List(ctx context.Context) ([]*Subscription, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// Enqueue queues a delivery of payload to every active subscription that
+// wants eventType and returns how many were queued. An event that was
+// already queued for a subscription, e.g. when dead letters are replayed,
+// is not queued again.
+func (s *Store) Enqueue(ctx context.Context, eventID, eventType string, companyID int64, payload []byte) (int64, error) {
+	result, err := s.DB.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, company_id, payload)
+		 SELECT id, $1, $2, $3, $4 FROM webhook_subscriptions
+		 WHERE active AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+		 ON CONFLICT (subscription_id, event_id) DO NOTHING`,
+		eventID, eventType, companyID, payload,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deliveryColumns = "d.id, d.subscription_id, d.event_id, d.event_type, d.company_id, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at"
+
+func scanDelivery(row rowScanner, extra ...interface{}) (*Delivery, error) {
+	var d Delivery
+	dest := []interface{}{&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.CompanyID, &d.Payload,
+		&d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt, &d.DeliveredAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Claim returns up to limit deliveries that are due, for subscriptions that
+// are active, and pushes their next attempt back by lease so that other
+// instances leave them alone while they are being sent.
+func (s *Store) Claim(ctx context.Context, limit int, lease time.Duration) ([]*Delivery, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		`UPDATE webhook_deliveries d SET next_attempt_at = NOW() + make_interval(secs => $2)
+		 FROM webhook_subscriptions s
+		 WHERE s.id = d.subscription_id AND d.id IN (
+		     SELECT due.id FROM webhook_deliveries due
+		     JOIN webhook_subscriptions sub ON sub.id = due.subscription_id
+		     WHERE due.status = 'PENDING' AND due.next_attempt_at <= NOW() AND sub.active
+		     ORDER BY due.next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE OF due SKIP LOCKED
+		 )
+		 RETURNING `+deliveryColumns+`, s.url, s.secret`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt logs an attempt to send d. A successful attempt completes
+// the delivery and clears the subscription's failures. A failed one is
+// retried at retryAt, or fails the delivery when retryAt is zero; the
+// subscription is disabled once disableAfter deliveries in a row have
+// failed, or never when disableAfter is zero.
+func (s *Store) RecordAttempt(ctx context.Context, d *Delivery, attempt Attempt, retryAt time.Time, disableAfter int) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms) VALUES ($1, $2, $3, $4, $5)",
+		d.ID, attempt.AttemptedAt, attempt.StatusCode, attempt.Error, attempt.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case attempt.Error == "":
+		_, err = tx.ExecContext(ctx,
+			"UPDATE webhook_deliveries SET status = 'SUCCEEDED', attempts = attempts + 1, last_error = '', delivered_at = $2 WHERE id = $1",
+			d.ID, attempt.AttemptedAt)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, "UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1", d.SubscriptionID)
+		}
+	case !retryAt.IsZero():
+		_, err = tx.ExecContext(ctx,
+			"UPDATE webhook_deliveries SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1",
+			d.ID, attempt.Error, retryAt)
+	default:
+		_, err = tx.ExecContext(ctx,
+			"UPDATE webhook_deliveries SET status = 'FAILED', attempts = attempts + 1, last_error = $2 WHERE id = $1",
+			d.ID, attempt.Error)
+		if err == nil {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1,
+				     active = active AND NOT ($2 > 0 AND consecutive_failures + 1 >= $2),
+				     disabled_at = CASE WHEN active AND $2 > 0 AND consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END,
+				     disabled_reason = CASE WHEN active AND $2 > 0 AND consecutive_failures + 1 >= $2 THEN $3 ELSE disabled_reason END
+				 WHERE id = $1`,
+				d.SubscriptionID, disableAfter, fmt.Sprintf("%d deliveries in a row failed, the last with: %s", disableAfter, attempt.Error))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Deliveries lists deliveries with their attempts.
+func (s *Store) Deliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.SubscriptionID != 0 {
+		where("d.subscription_id = $%d", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		where("d.status = $%d", filter.Status)
+	}
+	if filter.BeforeID != 0 {
+		where("d.id < $%d", filter.BeforeID)
+	}
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries d"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY d.id DESC LIMIT $%d", len(args))
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*Delivery
+	byID := make(map[int64]*Delivery)
+	var ids []int64
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+		byID[d.ID] = d
+		ids = append(ids, d.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	attempts, err := s.DB.QueryContext(ctx,
+		"SELECT delivery_id, attempted_at, status_code, error, duration_ms FROM webhook_attempts WHERE delivery_id = ANY($1) ORDER BY id",
+		pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer attempts.Close()
+	for attempts.Next() {
+		var deliveryID, durationMs int64
+		var attempt Attempt
+		if err := attempts.Scan(&deliveryID, &attempt.AttemptedAt, &attempt.StatusCode, &attempt.Error, &durationMs); err != nil {
+			return nil, err
+		}
+		attempt.Duration = time.Duration(durationMs) * time.Millisecond
+		byID[deliveryID].AttemptLog = append(byID[deliveryID].AttemptLog, attempt)
+	}
+	return deliveries, attempts.Err()
+}
+
+// Redeliver queues a delivery to be sent again right away, with a fresh set
+// of retries. It returns sql.ErrNoRows when the delivery does not exist.
+func (s *Store) Redeliver(ctx context.Context, id int64) (*Delivery, error) {
+	return scanDelivery(s.DB.QueryRowContext(ctx,
+		`UPDATE webhook_deliveries d SET status = 'PENDING', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		 WHERE id = $1
+		 RETURNING `+deliveryColumns,
+		id))
+}
@@ -0,0 +1,212 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        v5.28.3
+// source: proto/company_commands.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A change pushed to the company commands topic. Messages are binary
+// protobuf with content-type application/x-protobuf, or JSON otherwise.
+type CompanyCommand struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Used as the idempotency key, so a redelivered command is applied once.
+	CommandId string `protobuf:"bytes,1,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	// Types that are assignable to Command:
+	//	*CompanyCommand_Create
+	//	*CompanyCommand_Update
+	//	*CompanyCommand_Delete
+	Command isCompanyCommand_Command `protobuf_oneof:"command"`
+}
+
+func (x *CompanyCommand) Reset() {
+	*x = CompanyCommand{}
+	mi := &file_proto_company_commands_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompanyCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompanyCommand) ProtoMessage() {}
+
+func (x *CompanyCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_company_commands_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompanyCommand.ProtoReflect.Descriptor instead.
+func (*CompanyCommand) Descriptor() ([]byte, []int) {
+	return file_proto_company_commands_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CompanyCommand) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (m *CompanyCommand) GetCommand() isCompanyCommand_Command {
+	if m != nil {
+		return m.Command
+	}
+	return nil
+}
+
+func (x *CompanyCommand) GetCreate() *CreateCompanyRequest {
+	if x, ok := x.GetCommand().(*CompanyCommand_Create); ok {
+		return x.Create
+	}
+	return nil
+}
+
+func (x *CompanyCommand) GetUpdate() *UpdateCompanyRequest {
+	if x, ok := x.GetCommand().(*CompanyCommand_Update); ok {
+		return x.Update
+	}
+	return nil
+}
+
+func (x *CompanyCommand) GetDelete() *DeleteCompanyRequest {
+	if x, ok := x.GetCommand().(*CompanyCommand_Delete); ok {
+		return x.Delete
+	}
+	return nil
+}
+
+type isCompanyCommand_Command interface {
+	isCompanyCommand_Command()
+}
+
+type CompanyCommand_Create struct {
+	Create *CreateCompanyRequest `protobuf:"bytes,2,opt,name=create,proto3,oneof"`
+}
+
+type CompanyCommand_Update struct {
+	Update *UpdateCompanyRequest `protobuf:"bytes,3,opt,name=update,proto3,oneof"`
+}
+
+type CompanyCommand_Delete struct {
+	Delete *DeleteCompanyRequest `protobuf:"bytes,4,opt,name=delete,proto3,oneof"`
+}
+
+func (*CompanyCommand_Create) isCompanyCommand_Command() {}
+
+func (*CompanyCommand_Update) isCompanyCommand_Command() {}
+
+func (*CompanyCommand_Delete) isCompanyCommand_Command() {}
+
+var File_proto_company_commands_proto protoreflect.FileDescriptor
+
+var file_proto_company_commands_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x5f,
+	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07,
+	0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x1a, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63,
+	0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe5, 0x01, 0x0a,
+	0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12,
+	0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x49, 0x64, 0x12, 0x37,
+	0x0a, 0x06, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d,
+	0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x43,
+	0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
+	0x06, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x12, 0x37, 0x0a, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e,
+	0x79, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x12, 0x37, 0x0a, 0x06, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1d, 0x2e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x43, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48,
+	0x00, 0x52, 0x06, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x09, 0x0a, 0x07, 0x63, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x42, 0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x73, 0x65, 0x66, 0x65, 0x72, 0x6f, 0x76, 0x72, 0x61, 0x6d, 0x69, 0x6e, 0x37,
+	0x2f, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x6e, 0x79, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_company_commands_proto_rawDescOnce sync.Once
+	file_proto_company_commands_proto_rawDescData = file_proto_company_commands_proto_rawDesc
+)
+
+func file_proto_company_commands_proto_rawDescGZIP() []byte {
+	file_proto_company_commands_proto_rawDescOnce.Do(func() {
+		file_proto_company_commands_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_company_commands_proto_rawDescData)
+	})
+	return file_proto_company_commands_proto_rawDescData
+}
+
+var file_proto_company_commands_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_proto_company_commands_proto_goTypes = []any{
+	(*CompanyCommand)(nil),       // 0: company.CompanyCommand
+	(*CreateCompanyRequest)(nil), // 1: company.CreateCompanyRequest
+	(*UpdateCompanyRequest)(nil), // 2: company.UpdateCompanyRequest
+	(*DeleteCompanyRequest)(nil), // 3: company.DeleteCompanyRequest
+}
+var file_proto_company_commands_proto_depIdxs = []int32{
+	1, // 0: company.CompanyCommand.create:type_name -> company.CreateCompanyRequest
+	2, // 1: company.CompanyCommand.update:type_name -> company.UpdateCompanyRequest
+	3, // 2: company.CompanyCommand.delete:type_name -> company.DeleteCompanyRequest
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_company_commands_proto_init() }
+func file_proto_company_commands_proto_init() {
+	if File_proto_company_commands_proto != nil {
+		return
+	}
+	file_proto_company_proto_init()
+	file_proto_company_commands_proto_msgTypes[0].OneofWrappers = []any{
+		(*CompanyCommand_Create)(nil),
+		(*CompanyCommand_Update)(nil),
+		(*CompanyCommand_Delete)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_company_commands_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_company_commands_proto_goTypes,
+		DependencyIndexes: file_proto_company_commands_proto_depIdxs,
+		MessageInfos:      file_proto_company_commands_proto_msgTypes,
+	}.Build()
+	File_proto_company_commands_proto = out.File
+	file_proto_company_commands_proto_rawDesc = nil
+	file_proto_company_commands_proto_goTypes = nil
+	file_proto_company_commands_proto_depIdxs = nil
+}
@@ -19,11 +19,31 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CompanyService_CreateCompany_FullMethodName = "/company.CompanyService/CreateCompany"
-	CompanyService_UpdateCompany_FullMethodName = "/company.CompanyService/UpdateCompany"
-	CompanyService_DeleteCompany_FullMethodName = "/company.CompanyService/DeleteCompany"
-	CompanyService_GetCompany_FullMethodName    = "/company.CompanyService/GetCompany"
-	CompanyService_Login_FullMethodName         = "/company.CompanyService/Login"
+	CompanyService_CreateCompany_FullMethodName         = "/company.CompanyService/CreateCompany"
+	CompanyService_UpdateCompany_FullMethodName         = "/company.CompanyService/UpdateCompany"
+	CompanyService_DeleteCompany_FullMethodName         = "/company.CompanyService/DeleteCompany"
+	CompanyService_RestoreCompany_FullMethodName        = "/company.CompanyService/RestoreCompany"
+	CompanyService_BatchCreateCompanies_FullMethodName  = "/company.CompanyService/BatchCreateCompanies"
+	CompanyService_BatchUpdateCompanies_FullMethodName  = "/company.CompanyService/BatchUpdateCompanies"
+	CompanyService_BatchDeleteCompanies_FullMethodName  = "/company.CompanyService/BatchDeleteCompanies"
+	CompanyService_ImportCompanies_FullMethodName       = "/company.CompanyService/ImportCompanies"
+	CompanyService_ExportCompanies_FullMethodName       = "/company.CompanyService/ExportCompanies"
+	CompanyService_WatchCompanies_FullMethodName        = "/company.CompanyService/WatchCompanies"
+	CompanyService_RepublishSnapshot_FullMethodName     = "/company.CompanyService/RepublishSnapshot"
+	CompanyService_GetCompany_FullMethodName            = "/company.CompanyService/GetCompany"
+	CompanyService_GetCompanyByKey_FullMethodName       = "/company.CompanyService/GetCompanyByKey"
+	CompanyService_ListCompanies_FullMethodName         = "/company.CompanyService/ListCompanies"
+	CompanyService_GetCompanyHistory_FullMethodName     = "/company.CompanyService/GetCompanyHistory"
+	CompanyService_Login_FullMethodName                 = "/company.CompanyService/Login"
+	CompanyService_ListAuditEntries_FullMethodName      = "/company.CompanyService/ListAuditEntries"
+	CompanyService_VerifyAuditChain_FullMethodName      = "/company.CompanyService/VerifyAuditChain"
+	CompanyService_ExportAuditCheckpoint_FullMethodName = "/company.CompanyService/ExportAuditCheckpoint"
+	CompanyService_CreateWebhook_FullMethodName         = "/company.CompanyService/CreateWebhook"
+	CompanyService_UpdateWebhook_FullMethodName         = "/company.CompanyService/UpdateWebhook"
+	CompanyService_DeleteWebhook_FullMethodName         = "/company.CompanyService/DeleteWebhook"
+	CompanyService_ListWebhooks_FullMethodName          = "/company.CompanyService/ListWebhooks"
+	CompanyService_ListWebhookDeliveries_FullMethodName = "/company.CompanyService/ListWebhookDeliveries"
+	CompanyService_RedeliverWebhook_FullMethodName      = "/company.CompanyService/RedeliverWebhook"
 )
 
 // CompanyServiceClient is the client API for CompanyService service.
@@ -33,9 +53,28 @@ type CompanyServiceClient interface {
 	CreateCompany(ctx context.Context, in *CreateCompanyRequest, opts ...grpc.CallOption) (*CreateCompanyResponse, error)
 	UpdateCompany(ctx context.Context, in *UpdateCompanyRequest, opts ...grpc.CallOption) (*UpdateCompanyResponse, error)
 	DeleteCompany(ctx context.Context, in *DeleteCompanyRequest, opts ...grpc.CallOption) (*CompanyID, error)
-	GetCompany(ctx context.Context, in *CompanyID, opts ...grpc.CallOption) (*GetCompanyResponse, error)
-	// New method for user login to get a JWT
+	RestoreCompany(ctx context.Context, in *RestoreCompanyRequest, opts ...grpc.CallOption) (*RestoreCompanyResponse, error)
+	BatchCreateCompanies(ctx context.Context, in *BatchCreateCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error)
+	BatchUpdateCompanies(ctx context.Context, in *BatchUpdateCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error)
+	BatchDeleteCompanies(ctx context.Context, in *BatchDeleteCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error)
+	ImportCompanies(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCompaniesRequest, ImportCompaniesResponse], error)
+	ExportCompanies(ctx context.Context, in *ExportCompaniesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportCompaniesChunk], error)
+	WatchCompanies(ctx context.Context, in *WatchCompaniesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompanyChangeEvent], error)
+	RepublishSnapshot(ctx context.Context, in *RepublishSnapshotRequest, opts ...grpc.CallOption) (*RepublishSnapshotResponse, error)
+	GetCompany(ctx context.Context, in *GetCompanyRequest, opts ...grpc.CallOption) (*GetCompanyResponse, error)
+	GetCompanyByKey(ctx context.Context, in *GetCompanyByKeyRequest, opts ...grpc.CallOption) (*GetCompanyResponse, error)
+	ListCompanies(ctx context.Context, in *ListCompaniesRequest, opts ...grpc.CallOption) (*ListCompaniesResponse, error)
+	GetCompanyHistory(ctx context.Context, in *GetCompanyHistoryRequest, opts ...grpc.CallOption) (*GetCompanyHistoryResponse, error)
 	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+	ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error)
+	VerifyAuditChain(ctx context.Context, in *VerifyAuditChainRequest, opts ...grpc.CallOption) (*VerifyAuditChainResponse, error)
+	ExportAuditCheckpoint(ctx context.Context, in *ExportAuditCheckpointRequest, opts ...grpc.CallOption) (*AuditCheckpoint, error)
+	CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, in *UpdateWebhookRequest, opts ...grpc.CallOption) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error)
+	ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error)
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
+	RedeliverWebhook(ctx context.Context, in *RedeliverWebhookRequest, opts ...grpc.CallOption) (*WebhookDelivery, error)
 }
 
 type companyServiceClient struct {
@@ -76,7 +115,108 @@ func (c *companyServiceClient) DeleteCompany(ctx context.Context, in *DeleteComp
 	return out, nil
 }
 
-func (c *companyServiceClient) GetCompany(ctx context.Context, in *CompanyID, opts ...grpc.CallOption) (*GetCompanyResponse, error) {
+func (c *companyServiceClient) RestoreCompany(ctx context.Context, in *RestoreCompanyRequest, opts ...grpc.CallOption) (*RestoreCompanyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreCompanyResponse)
+	err := c.cc.Invoke(ctx, CompanyService_RestoreCompany_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) BatchCreateCompanies(ctx context.Context, in *BatchCreateCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCompaniesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_BatchCreateCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) BatchUpdateCompanies(ctx context.Context, in *BatchUpdateCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCompaniesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_BatchUpdateCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) BatchDeleteCompanies(ctx context.Context, in *BatchDeleteCompaniesRequest, opts ...grpc.CallOption) (*BatchCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCompaniesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_BatchDeleteCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) ImportCompanies(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportCompaniesRequest, ImportCompaniesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompanyService_ServiceDesc.Streams[0], CompanyService_ImportCompanies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportCompaniesRequest, ImportCompaniesResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_ImportCompaniesClient = grpc.ClientStreamingClient[ImportCompaniesRequest, ImportCompaniesResponse]
+
+func (c *companyServiceClient) ExportCompanies(ctx context.Context, in *ExportCompaniesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportCompaniesChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompanyService_ServiceDesc.Streams[1], CompanyService_ExportCompanies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportCompaniesRequest, ExportCompaniesChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_ExportCompaniesClient = grpc.ServerStreamingClient[ExportCompaniesChunk]
+
+func (c *companyServiceClient) WatchCompanies(ctx context.Context, in *WatchCompaniesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CompanyChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CompanyService_ServiceDesc.Streams[2], CompanyService_WatchCompanies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCompaniesRequest, CompanyChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_WatchCompaniesClient = grpc.ServerStreamingClient[CompanyChangeEvent]
+
+func (c *companyServiceClient) RepublishSnapshot(ctx context.Context, in *RepublishSnapshotRequest, opts ...grpc.CallOption) (*RepublishSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RepublishSnapshotResponse)
+	err := c.cc.Invoke(ctx, CompanyService_RepublishSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) GetCompany(ctx context.Context, in *GetCompanyRequest, opts ...grpc.CallOption) (*GetCompanyResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetCompanyResponse)
 	err := c.cc.Invoke(ctx, CompanyService_GetCompany_FullMethodName, in, out, cOpts...)
@@ -86,6 +226,36 @@ func (c *companyServiceClient) GetCompany(ctx context.Context, in *CompanyID, op
 	return out, nil
 }
 
+func (c *companyServiceClient) GetCompanyByKey(ctx context.Context, in *GetCompanyByKeyRequest, opts ...grpc.CallOption) (*GetCompanyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCompanyResponse)
+	err := c.cc.Invoke(ctx, CompanyService_GetCompanyByKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) ListCompanies(ctx context.Context, in *ListCompaniesRequest, opts ...grpc.CallOption) (*ListCompaniesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCompaniesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_ListCompanies_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) GetCompanyHistory(ctx context.Context, in *GetCompanyHistoryRequest, opts ...grpc.CallOption) (*GetCompanyHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCompanyHistoryResponse)
+	err := c.cc.Invoke(ctx, CompanyService_GetCompanyHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *companyServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LoginResponse)
@@ -96,6 +266,96 @@ func (c *companyServiceClient) Login(ctx context.Context, in *LoginRequest, opts
 	return out, nil
 }
 
+func (c *companyServiceClient) ListAuditEntries(ctx context.Context, in *ListAuditEntriesRequest, opts ...grpc.CallOption) (*ListAuditEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEntriesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_ListAuditEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) VerifyAuditChain(ctx context.Context, in *VerifyAuditChainRequest, opts ...grpc.CallOption) (*VerifyAuditChainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyAuditChainResponse)
+	err := c.cc.Invoke(ctx, CompanyService_VerifyAuditChain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) ExportAuditCheckpoint(ctx context.Context, in *ExportAuditCheckpointRequest, opts ...grpc.CallOption) (*AuditCheckpoint, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuditCheckpoint)
+	err := c.cc.Invoke(ctx, CompanyService_ExportAuditCheckpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) CreateWebhook(ctx context.Context, in *CreateWebhookRequest, opts ...grpc.CallOption) (*Webhook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Webhook)
+	err := c.cc.Invoke(ctx, CompanyService_CreateWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) UpdateWebhook(ctx context.Context, in *UpdateWebhookRequest, opts ...grpc.CallOption) (*Webhook, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Webhook)
+	err := c.cc.Invoke(ctx, CompanyService_UpdateWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) DeleteWebhook(ctx context.Context, in *DeleteWebhookRequest, opts ...grpc.CallOption) (*DeleteWebhookResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWebhookResponse)
+	err := c.cc.Invoke(ctx, CompanyService_DeleteWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) ListWebhooks(ctx context.Context, in *ListWebhooksRequest, opts ...grpc.CallOption) (*ListWebhooksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhooksResponse)
+	err := c.cc.Invoke(ctx, CompanyService_ListWebhooks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, CompanyService_ListWebhookDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *companyServiceClient) RedeliverWebhook(ctx context.Context, in *RedeliverWebhookRequest, opts ...grpc.CallOption) (*WebhookDelivery, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WebhookDelivery)
+	err := c.cc.Invoke(ctx, CompanyService_RedeliverWebhook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CompanyServiceServer is the server API for CompanyService service.
 // All implementations must embed UnimplementedCompanyServiceServer
 // for forward compatibility.
@@ -103,9 +363,28 @@ type CompanyServiceServer interface {
 	CreateCompany(context.Context, *CreateCompanyRequest) (*CreateCompanyResponse, error)
 	UpdateCompany(context.Context, *UpdateCompanyRequest) (*UpdateCompanyResponse, error)
 	DeleteCompany(context.Context, *DeleteCompanyRequest) (*CompanyID, error)
-	GetCompany(context.Context, *CompanyID) (*GetCompanyResponse, error)
-	// New method for user login to get a JWT
+	RestoreCompany(context.Context, *RestoreCompanyRequest) (*RestoreCompanyResponse, error)
+	BatchCreateCompanies(context.Context, *BatchCreateCompaniesRequest) (*BatchCompaniesResponse, error)
+	BatchUpdateCompanies(context.Context, *BatchUpdateCompaniesRequest) (*BatchCompaniesResponse, error)
+	BatchDeleteCompanies(context.Context, *BatchDeleteCompaniesRequest) (*BatchCompaniesResponse, error)
+	ImportCompanies(grpc.ClientStreamingServer[ImportCompaniesRequest, ImportCompaniesResponse]) error
+	ExportCompanies(*ExportCompaniesRequest, grpc.ServerStreamingServer[ExportCompaniesChunk]) error
+	WatchCompanies(*WatchCompaniesRequest, grpc.ServerStreamingServer[CompanyChangeEvent]) error
+	RepublishSnapshot(context.Context, *RepublishSnapshotRequest) (*RepublishSnapshotResponse, error)
+	GetCompany(context.Context, *GetCompanyRequest) (*GetCompanyResponse, error)
+	GetCompanyByKey(context.Context, *GetCompanyByKeyRequest) (*GetCompanyResponse, error)
+	ListCompanies(context.Context, *ListCompaniesRequest) (*ListCompaniesResponse, error)
+	GetCompanyHistory(context.Context, *GetCompanyHistoryRequest) (*GetCompanyHistoryResponse, error)
 	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error)
+	VerifyAuditChain(context.Context, *VerifyAuditChainRequest) (*VerifyAuditChainResponse, error)
+	ExportAuditCheckpoint(context.Context, *ExportAuditCheckpointRequest) (*AuditCheckpoint, error)
+	CreateWebhook(context.Context, *CreateWebhookRequest) (*Webhook, error)
+	UpdateWebhook(context.Context, *UpdateWebhookRequest) (*Webhook, error)
+	DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error)
+	ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
+	RedeliverWebhook(context.Context, *RedeliverWebhookRequest) (*WebhookDelivery, error)
 	mustEmbedUnimplementedCompanyServiceServer()
 }
 
@@ -125,12 +404,72 @@ func (UnimplementedCompanyServiceServer) UpdateCompany(context.Context, *UpdateC
 func (UnimplementedCompanyServiceServer) DeleteCompany(context.Context, *DeleteCompanyRequest) (*CompanyID, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteCompany not implemented")
 }
-func (UnimplementedCompanyServiceServer) GetCompany(context.Context, *CompanyID) (*GetCompanyResponse, error) {
+func (UnimplementedCompanyServiceServer) RestoreCompany(context.Context, *RestoreCompanyRequest) (*RestoreCompanyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreCompany not implemented")
+}
+func (UnimplementedCompanyServiceServer) BatchCreateCompanies(context.Context, *BatchCreateCompaniesRequest) (*BatchCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) BatchUpdateCompanies(context.Context, *BatchUpdateCompaniesRequest) (*BatchCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) BatchDeleteCompanies(context.Context, *BatchDeleteCompaniesRequest) (*BatchCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) ImportCompanies(grpc.ClientStreamingServer[ImportCompaniesRequest, ImportCompaniesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) ExportCompanies(*ExportCompaniesRequest, grpc.ServerStreamingServer[ExportCompaniesChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) WatchCompanies(*WatchCompaniesRequest, grpc.ServerStreamingServer[CompanyChangeEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) RepublishSnapshot(context.Context, *RepublishSnapshotRequest) (*RepublishSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RepublishSnapshot not implemented")
+}
+func (UnimplementedCompanyServiceServer) GetCompany(context.Context, *GetCompanyRequest) (*GetCompanyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCompany not implemented")
 }
+func (UnimplementedCompanyServiceServer) GetCompanyByKey(context.Context, *GetCompanyByKeyRequest) (*GetCompanyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCompanyByKey not implemented")
+}
+func (UnimplementedCompanyServiceServer) ListCompanies(context.Context, *ListCompaniesRequest) (*ListCompaniesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCompanies not implemented")
+}
+func (UnimplementedCompanyServiceServer) GetCompanyHistory(context.Context, *GetCompanyHistoryRequest) (*GetCompanyHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCompanyHistory not implemented")
+}
 func (UnimplementedCompanyServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
 }
+func (UnimplementedCompanyServiceServer) ListAuditEntries(context.Context, *ListAuditEntriesRequest) (*ListAuditEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEntries not implemented")
+}
+func (UnimplementedCompanyServiceServer) VerifyAuditChain(context.Context, *VerifyAuditChainRequest) (*VerifyAuditChainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyAuditChain not implemented")
+}
+func (UnimplementedCompanyServiceServer) ExportAuditCheckpoint(context.Context, *ExportAuditCheckpointRequest) (*AuditCheckpoint, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportAuditCheckpoint not implemented")
+}
+func (UnimplementedCompanyServiceServer) CreateWebhook(context.Context, *CreateWebhookRequest) (*Webhook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhook not implemented")
+}
+func (UnimplementedCompanyServiceServer) UpdateWebhook(context.Context, *UpdateWebhookRequest) (*Webhook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateWebhook not implemented")
+}
+func (UnimplementedCompanyServiceServer) DeleteWebhook(context.Context, *DeleteWebhookRequest) (*DeleteWebhookResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhook not implemented")
+}
+func (UnimplementedCompanyServiceServer) ListWebhooks(context.Context, *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhooks not implemented")
+}
+func (UnimplementedCompanyServiceServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
+func (UnimplementedCompanyServiceServer) RedeliverWebhook(context.Context, *RedeliverWebhookRequest) (*WebhookDelivery, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeliverWebhook not implemented")
+}
 func (UnimplementedCompanyServiceServer) mustEmbedUnimplementedCompanyServiceServer() {}
 func (UnimplementedCompanyServiceServer) testEmbeddedByValue()                        {}
 
@@ -206,8 +545,127 @@ func _CompanyService_DeleteCompany_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CompanyService_RestoreCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreCompanyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).RestoreCompany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_RestoreCompany_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).RestoreCompany(ctx, req.(*RestoreCompanyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_BatchCreateCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).BatchCreateCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_BatchCreateCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).BatchCreateCompanies(ctx, req.(*BatchCreateCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_BatchUpdateCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).BatchUpdateCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_BatchUpdateCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).BatchUpdateCompanies(ctx, req.(*BatchUpdateCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_BatchDeleteCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).BatchDeleteCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_BatchDeleteCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).BatchDeleteCompanies(ctx, req.(*BatchDeleteCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_ImportCompanies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CompanyServiceServer).ImportCompanies(&grpc.GenericServerStream[ImportCompaniesRequest, ImportCompaniesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_ImportCompaniesServer = grpc.ClientStreamingServer[ImportCompaniesRequest, ImportCompaniesResponse]
+
+func _CompanyService_ExportCompanies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportCompaniesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompanyServiceServer).ExportCompanies(m, &grpc.GenericServerStream[ExportCompaniesRequest, ExportCompaniesChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_ExportCompaniesServer = grpc.ServerStreamingServer[ExportCompaniesChunk]
+
+func _CompanyService_WatchCompanies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCompaniesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CompanyServiceServer).WatchCompanies(m, &grpc.GenericServerStream[WatchCompaniesRequest, CompanyChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CompanyService_WatchCompaniesServer = grpc.ServerStreamingServer[CompanyChangeEvent]
+
+func _CompanyService_RepublishSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RepublishSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).RepublishSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_RepublishSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).RepublishSnapshot(ctx, req.(*RepublishSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CompanyService_GetCompany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CompanyID)
+	in := new(GetCompanyRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -219,7 +677,61 @@ func _CompanyService_GetCompany_Handler(srv interface{}, ctx context.Context, de
 		FullMethod: CompanyService_GetCompany_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(CompanyServiceServer).GetCompany(ctx, req.(*CompanyID))
+		return srv.(CompanyServiceServer).GetCompany(ctx, req.(*GetCompanyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_GetCompanyByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCompanyByKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).GetCompanyByKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_GetCompanyByKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).GetCompanyByKey(ctx, req.(*GetCompanyByKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_ListCompanies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCompaniesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).ListCompanies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_ListCompanies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).ListCompanies(ctx, req.(*ListCompaniesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_GetCompanyHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCompanyHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).GetCompanyHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_GetCompanyHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).GetCompanyHistory(ctx, req.(*GetCompanyHistoryRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -242,6 +754,168 @@ func _CompanyService_Login_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CompanyService_ListAuditEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).ListAuditEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_ListAuditEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).ListAuditEntries(ctx, req.(*ListAuditEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_VerifyAuditChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyAuditChainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).VerifyAuditChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_VerifyAuditChain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).VerifyAuditChain(ctx, req.(*VerifyAuditChainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_ExportAuditCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportAuditCheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).ExportAuditCheckpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_ExportAuditCheckpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).ExportAuditCheckpoint(ctx, req.(*ExportAuditCheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_CreateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).CreateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_CreateWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).CreateWebhook(ctx, req.(*CreateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_UpdateWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).UpdateWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_UpdateWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).UpdateWebhook(ctx, req.(*UpdateWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_DeleteWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).DeleteWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_DeleteWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).DeleteWebhook(ctx, req.(*DeleteWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_ListWebhooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhooksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).ListWebhooks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_ListWebhooks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).ListWebhooks(ctx, req.(*ListWebhooksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_ListWebhookDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CompanyService_RedeliverWebhook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeliverWebhookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CompanyServiceServer).RedeliverWebhook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CompanyService_RedeliverWebhook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CompanyServiceServer).RedeliverWebhook(ctx, req.(*RedeliverWebhookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CompanyService_ServiceDesc is the grpc.ServiceDesc for CompanyService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -261,15 +935,99 @@ var CompanyService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteCompany",
 			Handler:    _CompanyService_DeleteCompany_Handler,
 		},
+		{
+			MethodName: "RestoreCompany",
+			Handler:    _CompanyService_RestoreCompany_Handler,
+		},
+		{
+			MethodName: "BatchCreateCompanies",
+			Handler:    _CompanyService_BatchCreateCompanies_Handler,
+		},
+		{
+			MethodName: "BatchUpdateCompanies",
+			Handler:    _CompanyService_BatchUpdateCompanies_Handler,
+		},
+		{
+			MethodName: "BatchDeleteCompanies",
+			Handler:    _CompanyService_BatchDeleteCompanies_Handler,
+		},
+		{
+			MethodName: "RepublishSnapshot",
+			Handler:    _CompanyService_RepublishSnapshot_Handler,
+		},
 		{
 			MethodName: "GetCompany",
 			Handler:    _CompanyService_GetCompany_Handler,
 		},
+		{
+			MethodName: "GetCompanyByKey",
+			Handler:    _CompanyService_GetCompanyByKey_Handler,
+		},
+		{
+			MethodName: "ListCompanies",
+			Handler:    _CompanyService_ListCompanies_Handler,
+		},
+		{
+			MethodName: "GetCompanyHistory",
+			Handler:    _CompanyService_GetCompanyHistory_Handler,
+		},
 		{
 			MethodName: "Login",
 			Handler:    _CompanyService_Login_Handler,
 		},
+		{
+			MethodName: "ListAuditEntries",
+			Handler:    _CompanyService_ListAuditEntries_Handler,
+		},
+		{
+			MethodName: "VerifyAuditChain",
+			Handler:    _CompanyService_VerifyAuditChain_Handler,
+		},
+		{
+			MethodName: "ExportAuditCheckpoint",
+			Handler:    _CompanyService_ExportAuditCheckpoint_Handler,
+		},
+		{
+			MethodName: "CreateWebhook",
+			Handler:    _CompanyService_CreateWebhook_Handler,
+		},
+		{
+			MethodName: "UpdateWebhook",
+			Handler:    _CompanyService_UpdateWebhook_Handler,
+		},
+		{
+			MethodName: "DeleteWebhook",
+			Handler:    _CompanyService_DeleteWebhook_Handler,
+		},
+		{
+			MethodName: "ListWebhooks",
+			Handler:    _CompanyService_ListWebhooks_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _CompanyService_ListWebhookDeliveries_Handler,
+		},
+		{
+			MethodName: "RedeliverWebhook",
+			Handler:    _CompanyService_RedeliverWebhook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportCompanies",
+			Handler:       _CompanyService_ImportCompanies_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportCompanies",
+			Handler:       _CompanyService_ExportCompanies_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchCompanies",
+			Handler:       _CompanyService_WatchCompanies_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/company.proto",
 }